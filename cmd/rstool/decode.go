@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+
+	rs "github.com/templexxx/reedsolomon"
+)
+
+func runDecode(args []string) (err error) {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	out := fs.String("o", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	s, err := openShards(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	stream, err := rs.NewStream(s.codec, s.h.BlockSize)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *out != "" {
+		var f *os.File
+		f, err = os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(*out)
+			}
+		}()
+		w = f
+	}
+	bw := bufio.NewWriterSize(w, ioBufSize)
+	err = stream.Decode(bw, s.readers, s.h.ObjectSize)
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
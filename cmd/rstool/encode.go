@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	rs "github.com/templexxx/reedsolomon"
+)
+
+var matrixTypes = map[string]rs.MatrixType{
+	"cauchy":      rs.CauchyMatrix,
+	"vandermonde": rs.VandermondeMatrix,
+}
+
+func runEncode(args []string) error {
+	fs := flag.NewFlagSet("encode", flag.ExitOnError)
+	data := fs.Int("d", 10, "number of data shards")
+	parity := fs.Int("p", 4, "number of parity shards")
+	blockSize := fs.Int("b", 64*1024, "size of each shard's block, "+
+		"memory usage is about (data+parity)*blocksize")
+	mt := fs.String("m", "cauchy", "encoding matrix: cauchy or vandermonde")
+	poly := fs.Int("poly", rs.DefaultPolynomial, "polynomial of GF(2^8)")
+	dir := fs.String("o", "", "output directory of shard files (default: directory of file)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("need exactly one file")
+	}
+	file := fs.Arg(0)
+
+	matrix, ok := matrixTypes[*mt]
+	if !ok {
+		return fmt.Errorf("unknown matrix: %s", *mt)
+	}
+	codec, err := rs.New(*data, *parity, rs.WithMatrix(matrix), rs.WithPolynomial(*poly))
+	if err != nil {
+		return err
+	}
+	stream, err := rs.NewStream(codec, *blockSize)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	out := filepath.Dir(file)
+	if *dir != "" {
+		out = *dir
+	}
+	base := filepath.Join(out, filepath.Base(file))
+	err = encodeShards(stream, bufio.NewReaderSize(f, ioBufSize), fi.Size(), base)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// encodeShards encodes size bytes from r into shard files base.i,
+// shard files are removed if it fails.
+func encodeShards(stream *rs.Stream, r io.Reader, size int64, base string) (err error) {
+	h := stream.ShardHeader(0, size)
+	n := h.DataNum + h.ParityNum
+	ws := make([]*shardFileWriter, n)
+	dst := make([]io.Writer, n)
+	defer func() {
+		if err != nil {
+			for i, w := range ws {
+				if w != nil {
+					w.f.Close()
+					os.Remove(shardName(base, i))
+				}
+			}
+		}
+	}()
+	for i := range ws {
+		ws[i], err = createShard(shardName(base, i), stream.ShardHeader(i, size))
+		if err != nil {
+			return err
+		}
+		dst[i] = ws[i]
+	}
+
+	encoded, err := stream.Encode(r, dst)
+	if err != nil {
+		return err
+	}
+	if encoded != size {
+		return fmt.Errorf("size changed while encoding: %d -> %d", size, encoded)
+	}
+	for _, w := range ws {
+		err = w.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+// This tool encodes a file into shard files, decodes shard files
+// back to the original file, and verifies/repairs shard files.
+//
+// Shard files use the library's shard file format (see reedsolomon.ShardHeader),
+// and shard i of file is named file.i.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// stdout is the output of reports and decoded files, replaced in tests.
+var stdout io.Writer = os.Stdout
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"encode", "encode [-d data] [-p parity] [-m matrix] [-poly polynomial] [-b blocksize] [-o dir] file", runEncode},
+	{"decode", "decode [-o file] shard...", runDecode},
+	{"verify", "verify [-json] shard...", runVerify},
+	{"repair", "repair [-json] shard...", runRepair},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  rstool %s\n", c.usage)
+	}
+	fmt.Fprintln(os.Stderr, "  Run 'rstool <command> -h' for flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			err := c.run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "rstool %s: %v\n", c.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
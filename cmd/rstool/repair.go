@@ -0,0 +1,150 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	rs "github.com/templexxx/reedsolomon"
+)
+
+func runRepair(args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "print report in JSON")
+	fs.Parse(args)
+
+	rep, err := checkShards(fs.Args())
+	if err != nil {
+		return err
+	}
+	if rep.Healthy {
+		return printReport(rep, *jsonOut)
+	}
+	if !rep.Repairable {
+		printReport(rep, *jsonOut)
+		return errors.New("shards are unrepairable")
+	}
+
+	err = repairShards(rep)
+	if err != nil {
+		return err
+	}
+
+	// Check again, for making sure repaired shards are good.
+	repaired := rep.Bad
+	rep, err = checkShards(shardPaths(rep))
+	if err != nil {
+		return err
+	}
+	rep.Repaired = repaired
+	err = printReport(rep, *jsonOut)
+	if err != nil {
+		return err
+	}
+	if !rep.Healthy {
+		return errors.New("shards are still unhealthy after repair")
+	}
+	return nil
+}
+
+// repairShards regenerates bad shards in rep from good ones.
+// Bad shards are written into temporary files first, and then renamed.
+func repairShards(rep *report) (err error) {
+	base, err := shardBase(rep)
+	if err != nil {
+		return
+	}
+
+	var good []string
+	for _, sr := range rep.Shards {
+		if sr.Status == statusOK {
+			good = append(good, sr.Path)
+		}
+	}
+	s, err := openShards(good)
+	if err != nil {
+		return
+	}
+	defer s.close()
+	stream, err := rs.NewStream(s.codec, s.h.BlockSize)
+	if err != nil {
+		return
+	}
+
+	ws := make([]*shardFileWriter, len(s.readers))
+	dst := make([]io.Writer, len(s.readers))
+	defer func() {
+		if err != nil {
+			for i, w := range ws {
+				if w != nil {
+					w.f.Close()
+					os.Remove(shardName(base, i) + ".tmp")
+				}
+			}
+		}
+	}()
+	for _, i := range rep.Bad {
+		ws[i], err = createShard(shardName(base, i)+".tmp", stream.ShardHeader(i, s.h.ObjectSize))
+		if err != nil {
+			return
+		}
+		dst[i] = ws[i]
+	}
+	err = stream.Reconst(s.readers, dst)
+	if err != nil {
+		return
+	}
+	for _, i := range rep.Bad {
+		err = ws[i].Close()
+		if err != nil {
+			return
+		}
+	}
+	for _, i := range rep.Bad {
+		err = os.Rename(shardName(base, i)+".tmp", shardName(base, i))
+		if err != nil {
+			return
+		}
+		rep.Shards[i].Path = shardName(base, i)
+	}
+	return nil
+}
+
+// shardBase returns the original file name of shards in rep,
+// shard files must be named as file.i (see shardName).
+func shardBase(rep *report) (base string, err error) {
+	for _, sr := range rep.Shards {
+		if sr.Path == "" {
+			continue
+		}
+		suffix := "." + strconv.Itoa(sr.Index)
+		if !strings.HasSuffix(sr.Path, suffix) {
+			return "", fmt.Errorf("%s: name of shard %d should end with %s", sr.Path, sr.Index, suffix)
+		}
+		b := strings.TrimSuffix(sr.Path, suffix)
+		if base != "" && b != base {
+			return "", fmt.Errorf("shards belong to different files: %s, %s", base, b)
+		}
+		base = b
+	}
+	return
+}
+
+func shardPaths(rep *report) []string {
+	paths := make([]string, 0, len(rep.Shards))
+	for _, sr := range rep.Shards {
+		if sr.Path != "" {
+			paths = append(paths, sr.Path)
+		}
+	}
+	return paths
+}
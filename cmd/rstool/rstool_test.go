@@ -0,0 +1,213 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	rs "github.com/templexxx/reedsolomon"
+)
+
+const (
+	testDataNum   = 4
+	testParityNum = 3
+	testBlockSize = 4096
+	testFileSize  = 10*testDataNum*testBlockSize + 123
+	headerSize    = 36 // Shard file header size.
+)
+
+func TestRSTool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rstool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "file")
+	obj := make([]byte, testFileSize)
+	rand.Read(obj)
+	err = ioutil.WriteFile(file, obj, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runEncode([]string{"-d", "4", "-p", "3", "-b", "4096", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shards := make([]string, testDataNum+testParityNum)
+	for i := range shards {
+		shards[i] = shardName(file, i)
+	}
+
+	// Shard 0 is silently corrupted: its block checksum is still right.
+	corruptBlock(t, shards[0], 1, true)
+	rep := runReport(t, runVerify, shards, false)
+	checkReport(t, rep, []int{0}, []int{4, 5, 6}, true)
+	if rep.Shards[0].Status != statusCorrupted {
+		t.Fatalf("shard 0 should be corrupted: %+v", rep.Shards[0])
+	}
+	rep = runReport(t, runRepair, shards, true)
+	if !reflect.DeepEqual(rep.Repaired, []int{0}) || !rep.Healthy {
+		t.Fatalf("repair mismatched: %+v", rep)
+	}
+
+	// Shard 1 is lost, and shard 5 has a bad block checksum.
+	err = os.Remove(shards[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptBlock(t, shards[5], 2, false)
+	rep = runReport(t, runVerify, shards, false)
+	checkReport(t, rep, []int{1, 5}, []int{}, false)
+	if rep.Shards[1].Status != statusMissing || rep.Shards[5].Status != statusUnread {
+		t.Fatalf("shard status mismatched: %+v", rep.Shards)
+	}
+	rep = runReport(t, runRepair, shards, true)
+	if !reflect.DeepEqual(rep.Repaired, []int{1, 5}) || !rep.Healthy {
+		t.Fatalf("repair mismatched: %+v", rep)
+	}
+	runReport(t, runVerify, shards, true)
+
+	// Decode from any dataNum shards.
+	out := filepath.Join(dir, "file.out")
+	err = runDecode([]string{"-o", out, shards[1], shards[2], shards[5], shards[6]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(act, obj) {
+		t.Fatal("decoded file mismatched")
+	}
+}
+
+// runReport runs verify/repair with -json, and returns the report.
+func runReport(t *testing.T, run func([]string) error, shards []string, healthy bool) *report {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	stdout = buf
+	defer func() { stdout = os.Stdout }()
+
+	args := []string{"-json"}
+	for _, s := range shards {
+		if _, err := os.Stat(s); err == nil { // As file.* in shell.
+			args = append(args, s)
+		}
+	}
+	err := run(args)
+	if healthy != (err == nil) {
+		t.Fatalf("healthy: %t, got error: %v", healthy, err)
+	}
+	rep := new(report)
+	err = json.Unmarshal(buf.Bytes(), rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rep
+}
+
+func checkReport(t *testing.T, rep *report, bad, parityMismatch []int, parityChecked bool) {
+	t.Helper()
+
+	if rep.DataNum != testDataNum || rep.ParityNum != testParityNum || rep.ObjectSize != testFileSize {
+		t.Fatalf("codec mismatched: %+v", rep)
+	}
+	if !reflect.DeepEqual(rep.Bad, bad) || !reflect.DeepEqual(rep.ParityMismatch, parityMismatch) ||
+		rep.ParityChecked != parityChecked {
+		t.Fatalf("report mismatched: %+v", rep)
+	}
+	if rep.Healthy || !rep.Repairable || rep.Inconsistent {
+		t.Fatalf("shards should be unhealthy but repairable: %+v", rep)
+	}
+}
+
+// corruptBlock flips a byte in block i of shard file path,
+// and fixes the block checksum if keepCRC.
+func corruptBlock(t *testing.T, path string, i int, keepCRC bool) {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := headerSize + i*(testBlockSize+4)
+	block := b[off : off+testBlockSize]
+	block[7] ^= 1
+	if keepCRC {
+		binary.LittleEndian.PutUint32(b[off+testBlockSize:],
+			crc32.Checksum(block, crc32.MakeTable(crc32.Castagnoli)))
+	}
+	err = ioutil.WriteFile(path, b, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodeShards_Cleanup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rstool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	codec, err := rs.New(testDataNum, testParityNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := rs.NewStream(codec, testBlockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := make([]byte, testFileSize)
+	rand.Read(obj)
+	base := filepath.Join(dir, "file")
+
+	// File shrank while encoding.
+	err = encodeShards(stream, bytes.NewReader(obj[:testFileSize-testBlockSize*testDataNum]), testFileSize, base)
+	if err == nil {
+		t.Fatal("size change should fail")
+	}
+	checkNoShards(t, dir)
+
+	// Shard 3 can't be created.
+	err = os.Mkdir(shardName(base, 3), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = encodeShards(stream, bytes.NewReader(obj), testFileSize, base)
+	if err == nil {
+		t.Fatal("creating shard 3 should fail")
+	}
+	err = os.Remove(shardName(base, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNoShards(t, dir)
+}
+
+func checkNoShards(t *testing.T, dir string) {
+	t.Helper()
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 0 {
+		t.Fatalf("shard files are left: %d files", len(fis))
+	}
+}
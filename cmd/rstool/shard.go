@@ -0,0 +1,125 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	rs "github.com/templexxx/reedsolomon"
+)
+
+const ioBufSize = 256 * 1024
+
+// shardName returns the name of shard i of file.
+func shardName(file string, i int) string {
+	return fmt.Sprintf("%s.%d", file, i)
+}
+
+// shardSet is a set of opened shard files of the same object.
+type shardSet struct {
+	h       rs.ShardHeader // Header shared by all shards, Index is meaningless.
+	codec   *rs.RS
+	paths   []string // paths[i] is "" if shard i is missing.
+	files   []*os.File
+	readers []io.Reader // readers[i] is nil if shard i is missing.
+}
+
+// openShards opens shard files, and checks that they are different shards
+// of the same object. Files which aren't shard files are errors.
+func openShards(paths []string) (s *shardSet, err error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no shard files")
+	}
+	s = new(shardSet)
+	defer func() {
+		if err != nil {
+			s.close()
+		}
+	}()
+
+	var first *rs.ShardHeader
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return s, err
+		}
+		s.files = append(s.files, f)
+		sr, err := rs.NewShardReader(bufio.NewReaderSize(f, ioBufSize))
+		if err != nil {
+			return s, fmt.Errorf("%s: %w", path, err)
+		}
+		h := sr.Header()
+		if first == nil {
+			first = &h
+			s.h = h
+			s.codec, err = rs.New(h.DataNum, h.ParityNum, rs.WithMatrix(h.Matrix),
+				rs.WithPolynomial(h.Polynomial))
+			if err != nil {
+				return s, fmt.Errorf("%s: %w", path, err)
+			}
+			s.paths = make([]string, h.DataNum+h.ParityNum)
+			s.readers = make([]io.Reader, h.DataNum+h.ParityNum)
+		}
+		err = h.Check(s.codec)
+		if err == nil {
+			err = h.SameObject(first)
+		}
+		if err != nil {
+			return s, fmt.Errorf("%s: %w", path, err)
+		}
+		if s.paths[h.Index] != "" {
+			return s, fmt.Errorf("%s and %s are both shard %d", s.paths[h.Index], path, h.Index)
+		}
+		s.paths[h.Index] = path
+		s.readers[h.Index] = sr
+	}
+	return s, nil
+}
+
+func (s *shardSet) close() {
+	for _, f := range s.files {
+		f.Close()
+	}
+}
+
+// shardFileWriter writes a shard file.
+type shardFileWriter struct {
+	f  *os.File
+	bw *bufio.Writer
+	*rs.ShardWriter
+}
+
+func createShard(path string, h rs.ShardHeader) (w *shardFileWriter, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	bw := bufio.NewWriterSize(f, ioBufSize)
+	sw, err := rs.NewShardWriter(bw, h)
+	if err != nil {
+		f.Close()
+		return
+	}
+	return &shardFileWriter{f: f, bw: bw, ShardWriter: sw}, nil
+}
+
+// Close writes the last block, flushes and closes the file.
+func (w *shardFileWriter) Close() error {
+	err := w.ShardWriter.Close()
+	if err == nil {
+		err = w.bw.Flush()
+	}
+	if err == nil {
+		err = w.f.Sync()
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
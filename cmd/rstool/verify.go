@@ -0,0 +1,196 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// Shard status.
+const (
+	statusOK        = "ok"
+	statusMissing   = "missing"
+	statusUnread    = "unreadable" // Checksum mismatch, truncated or I/O error.
+	statusCorrupted = "corrupted"  // Readable, but located by parity.
+)
+
+type shardReport struct {
+	Index  int    `json:"index"`
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// report is the result of checking a set of shard files.
+type report struct {
+	DataNum    int   `json:"dataNum"`
+	ParityNum  int   `json:"parityNum"`
+	ObjectSize int64 `json:"objectSize"`
+
+	Shards []shardReport `json:"shards"`
+	// Bad is the indexes of shards which are not ok.
+	Bad []int `json:"bad"`
+	// ParityMismatch is the indexes of parity shards which disagree with data shards.
+	ParityMismatch []int `json:"parityMismatch"`
+	// ParityChecked is false if parity can't be recomputed for some blocks,
+	// because some shards are missing or unreadable there.
+	ParityChecked bool `json:"parityChecked"`
+	// Inconsistent is true if parity mismatches but corrupted shards can't be located.
+	Inconsistent bool `json:"inconsistent"`
+	Healthy      bool `json:"healthy"`
+	Repairable   bool `json:"repairable"`
+	// Repaired is the indexes of shards regenerated by repair.
+	Repaired []int `json:"repaired,omitempty"`
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "print report in JSON")
+	fs.Parse(args)
+
+	rep, err := checkShards(fs.Args())
+	if err != nil {
+		return err
+	}
+	err = printReport(rep, *jsonOut)
+	if err != nil {
+		return err
+	}
+	if !rep.Healthy {
+		return errors.New("shards are not healthy")
+	}
+	return nil
+}
+
+// checkShards reads all blocks of shard files, and checks their checksums
+// and parity.
+func checkShards(paths []string) (rep *report, err error) {
+	s, err := openShards(paths)
+	if err != nil {
+		return
+	}
+	defer s.close()
+
+	d, p := s.h.DataNum, s.h.ParityNum
+	rep = &report{DataNum: d, ParityNum: p, ObjectSize: s.h.ObjectSize, ParityChecked: true,
+		Bad: []int{}, ParityMismatch: []int{}}
+	rep.Shards = make([]shardReport, d+p)
+	for i := range rep.Shards {
+		rep.Shards[i] = shardReport{Index: i, Path: s.paths[i], Status: statusOK}
+		if s.readers[i] == nil {
+			rep.Shards[i].Status = statusMissing
+		}
+	}
+
+	bs := s.h.BlockSize
+	buf := make([]byte, (d+p)*bs)
+	vects := make([][]byte, d+p)
+	mismatch := make([]bool, p)
+	for size := s.h.ShardSize(); size > 0; {
+		n := bs
+		if int64(n) > size {
+			n = int(size)
+		}
+		size -= int64(n)
+
+		all := true
+		for i, r := range s.readers {
+			sr := &rep.Shards[i]
+			if sr.Status == statusMissing || sr.Status == statusUnread {
+				all = false
+				continue
+			}
+			vects[i] = buf[i*bs : i*bs+n]
+			_, err = io.ReadFull(r, vects[i])
+			if err != nil {
+				sr.Status, sr.Error = statusUnread, err.Error()
+				all = false
+			}
+		}
+		if !all {
+			rep.ParityChecked = false
+			continue
+		}
+
+		ms, err := s.codec.VerifyMismatch(vects)
+		if err != nil {
+			return nil, err
+		}
+		if len(ms) == 0 {
+			continue
+		}
+		for _, m := range ms {
+			mismatch[m.Row-d] = true
+		}
+		corrupted, err := s.codec.Locate(vects)
+		if err != nil {
+			rep.Inconsistent = true
+			continue
+		}
+		for _, i := range corrupted {
+			rep.Shards[i].Status = statusCorrupted
+		}
+	}
+
+	for j, m := range mismatch {
+		if m {
+			rep.ParityMismatch = append(rep.ParityMismatch, d+j)
+		}
+	}
+	for _, sr := range rep.Shards {
+		if sr.Status != statusOK {
+			rep.Bad = append(rep.Bad, sr.Index)
+		}
+	}
+	rep.Healthy = len(rep.Bad) == 0 && !rep.Inconsistent
+	rep.Repairable = len(rep.Bad) <= p && !rep.Inconsistent
+	return rep, nil
+}
+
+func printReport(rep *report, jsonOut bool) error {
+	if jsonOut {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rep)
+	}
+
+	fmt.Fprintf(stdout, "codec: %d+%d, object size: %d\n", rep.DataNum, rep.ParityNum, rep.ObjectSize)
+	for _, sr := range rep.Shards {
+		if sr.Status == statusOK {
+			continue
+		}
+		fmt.Fprintf(stdout, "shard %d: %s", sr.Index, sr.Status)
+		if sr.Error != "" {
+			fmt.Fprintf(stdout, " (%s)", sr.Error)
+		}
+		fmt.Fprintln(stdout)
+	}
+	if len(rep.ParityMismatch) != 0 {
+		fmt.Fprintf(stdout, "parity mismatch: %v\n", rep.ParityMismatch)
+	}
+	if !rep.ParityChecked {
+		fmt.Fprintln(stdout, "parity not checked for all blocks: some shards are missing or unreadable")
+	}
+	if rep.Inconsistent {
+		fmt.Fprintln(stdout, "parity mismatches, but corrupted shards can't be located")
+	}
+	if len(rep.Repaired) != 0 {
+		fmt.Fprintf(stdout, "repaired: %v\n", rep.Repaired)
+	}
+	switch {
+	case rep.Healthy:
+		fmt.Fprintln(stdout, "healthy")
+	case rep.Repairable:
+		fmt.Fprintln(stdout, "unhealthy, repairable")
+	default:
+		fmt.Fprintln(stdout, "unhealthy, unrepairable")
+	}
+	return nil
+}
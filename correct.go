@@ -0,0 +1,249 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+	"sort"
+)
+
+var ErrTooManyCorrupted = errors.New("too many corrupted vectors to locate")
+
+// Locate finds silently corrupted vectors without modifying vects.
+// vects has the same layout as in Encode and all vectors must be present.
+//
+// Errors are located independently in every byte column, so up to
+// ParityNum/2 vectors may be corrupted at each byte offset,
+// and different offsets may have different corrupted vectors.
+// It returns the sorted indexes of all vectors which have at least one
+// corrupted byte, or ErrTooManyCorrupted if any byte column cannot be decoded.
+//
+// Warning:
+// Locating a corrupted byte column costs O(C(DataNum+ParityNum, ParityNum/2)),
+// which is cheap for common layouts (e.g. 10+4) but may be very slow for wide
+// stripes with many parity vectors. Consistent byte columns cost the same as Verify.
+func (r *RS) Locate(vects [][]byte) (corrupted []int, err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	return r.correct(vects, false)
+}
+
+// Correct is like Locate, but also repairs the corrupted bytes in place.
+// It returns the sorted indexes of the vectors it had to fix.
+// If err != nil, vects is left unmodified.
+func (r *RS) Correct(vects [][]byte) (fixed []int, err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	fixed, err = r.correct(vects, false)
+	if err != nil || len(fixed) == 0 {
+		return
+	}
+	// All byte columns are decodable now, it's safe to write.
+	return r.correct(vects, true)
+}
+
+// correct computes syndromes (parity XOR generator_matrix * data) chunk by chunk,
+// and locates errors in every byte column whose syndrome is not zero.
+// If apply is true, located errors are cleared in vects.
+func (r *RS) correct(vects [][]byte, apply bool) (idx []int, err error) {
+	d, p := r.DataNum, r.ParityNum
+	dv, pv := vects[:d], vects[d:]
+	size := len(vects[0])
+	splitSize := getSplitSize(size)
+
+	buf := make([]byte, p*splitSize)
+	dc := make([][]byte, d)
+	sc := make([][]byte, p)
+	lc := r.newColumnLocator()
+	found := make([]bool, d+p)
+
+	start := 0
+	for start < size {
+		end := start + splitSize
+		if end > size {
+			end = size
+		}
+		n := end - start
+		for i := range dc {
+			dc[i] = dv[i][start:end]
+		}
+		for j := range sc {
+			sc[j] = buf[j*splitSize : j*splitSize+n]
+			copy(sc[j], pv[j][start:end])
+		}
+		r.encodePart(r.GenMatrix, 0, n, dc, sc, true)
+
+		for x := 0; x < n; x++ {
+			zero := true
+			for j := range sc {
+				lc.syndrome[j] = sc[j][x]
+				if sc[j][x] != 0 {
+					zero = false
+				}
+			}
+			if zero {
+				continue
+			}
+			k, ok := lc.locate()
+			if !ok {
+				return nil, ErrTooManyCorrupted
+			}
+			for i, row := range lc.cols[:k] {
+				found[row] = true
+				if apply {
+					vects[row][start+x] ^= lc.errs[i]
+				}
+			}
+		}
+		start = end
+	}
+
+	for i, f := range found {
+		if f {
+			idx = append(idx, i)
+		}
+	}
+	sort.Ints(idx)
+	return
+}
+
+// columnLocator locates errors in a single byte column by its syndrome.
+//
+// The parity-check matrix is H = [GenMatrix | I], so for a column with error
+// values e, syndrome = H * e. Because the code is MDS (distance ParityNum+1),
+// any ParityNum columns of H are linearly independent, and an error pattern
+// with at most ParityNum/2 non-zero values is unique.
+// Patterns are tried in order of increasing weight.
+type columnLocator struct {
+	d, p, t int
+	g       matrix
+	f       *galoisField
+
+	syndrome []byte
+	cols     []int  // Error positions of the last located pattern.
+	errs     []byte // Error values of the last located pattern.
+	aug      []byte // Augmented matrix: p rows, t+1 columns.
+}
+
+func (r *RS) newColumnLocator() *columnLocator {
+	d, p := r.DataNum, r.ParityNum
+	t := p / 2
+	return &columnLocator{
+		d: d, p: p, t: t, g: r.GenMatrix, f: r.gf,
+		syndrome: make([]byte, p),
+		cols:     make([]int, t),
+		errs:     make([]byte, t),
+		aug:      make([]byte, p*(t+1)),
+	}
+}
+
+// locate tries all error patterns with weight in [1, t],
+// and returns the weight of the matched one.
+func (lc *columnLocator) locate() (k int, ok bool) {
+	n := lc.d + lc.p
+	for k = 1; k <= lc.t; k++ {
+		cols := lc.cols[:k]
+		for i := range cols {
+			cols[i] = i
+		}
+		for {
+			if lc.solve(k) {
+				return k, true
+			}
+			// Next combination in lexicographic order.
+			i := k - 1
+			for i >= 0 && cols[i] == n-k+i {
+				i--
+			}
+			if i < 0 {
+				break
+			}
+			cols[i]++
+			for j := i + 1; j < k; j++ {
+				cols[j] = cols[j-1] + 1
+			}
+		}
+	}
+	return 0, false
+}
+
+// hCoeff returns H[row][col] of the parity-check matrix.
+func (lc *columnLocator) hCoeff(row, col int) byte {
+	if col < lc.d {
+		return lc.g[row*lc.d+col]
+	}
+	if col-lc.d == row {
+		return 1
+	}
+	return 0
+}
+
+// solve checks whether syndrome = H[:, cols] * e has a solution with
+// all non-zero e, and stores e in lc.errs if it has.
+func (lc *columnLocator) solve(k int) bool {
+	p, w := lc.p, k+1
+	a := lc.aug[:p*w]
+	for j := 0; j < p; j++ {
+		for i, c := range lc.cols[:k] {
+			a[j*w+i] = lc.hCoeff(j, c)
+		}
+		a[j*w+k] = lc.syndrome[j]
+	}
+
+	for c := 0; c < k; c++ {
+		piv := -1
+		for j := c; j < p; j++ {
+			if a[j*w+c] != 0 {
+				piv = j
+				break
+			}
+		}
+		if piv < 0 {
+			return false
+		}
+		if piv != c {
+			for i := 0; i < w; i++ {
+				a[c*w+i], a[piv*w+i] = a[piv*w+i], a[c*w+i]
+			}
+		}
+		if a[c*w+c] != 1 {
+			v := lc.f.inv(a[c*w+c])
+			for i := c; i < w; i++ {
+				a[c*w+i] = lc.f.mul(a[c*w+i], v)
+			}
+		}
+		for j := 0; j < p; j++ {
+			if j == c {
+				continue
+			}
+			v := a[j*w+c]
+			if v != 0 {
+				for i := c; i < w; i++ {
+					a[j*w+i] ^= lc.f.mul(v, a[c*w+i])
+				}
+			}
+		}
+	}
+
+	// Over-determined rows must be consistent.
+	for j := k; j < p; j++ {
+		if a[j*w+k] != 0 {
+			return false
+		}
+	}
+	for c := 0; c < k; c++ {
+		e := a[c*w+k]
+		if e == 0 {
+			return false // A lighter pattern would have matched.
+		}
+		lc.errs[c] = e
+	}
+	return true
+}
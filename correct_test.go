@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRS_Correct(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	testCorrect(t, testDataNum, testParityNum, testSize, 64)
+	testCorrect(t, 5, 3, testSize, 64) // Odd parity: still corrects 1 vector per column.
+	testCorrect(t, 12, 6, 32*kib, 8)   // Multi-chunk.
+}
+
+func testCorrect(t *testing.T, d, p, size, loop int) {
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < loop; i++ {
+		exp := make([][]byte, d+p)
+		act := make([][]byte, d+p)
+		for j := range exp {
+			exp[j], act[j] = make([]byte, size), make([]byte, size)
+		}
+		for j := 0; j < d; j++ {
+			fillRandom(exp[j])
+		}
+		err = r.Encode(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range exp {
+			copy(act[j], exp[j])
+		}
+
+		// Corrupt up to p/2 vectors in a few random byte columns,
+		// each column has its own corrupted vectors.
+		corrupted := make(map[int]bool)
+		for _, off := range randPermK(newTestRand(), size, 8) {
+			for _, row := range randPermK(newTestRand(), d+p, rand.Intn(p/2+1)) {
+				act[row][off] ^= byte(rand.Intn(255) + 1)
+				corrupted[row] = true
+			}
+		}
+		expFixed := make([]int, 0, len(corrupted))
+		for row := range corrupted {
+			expFixed = append(expFixed, row)
+		}
+		sort.Ints(expFixed)
+
+		located, err := r.Locate(act)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(located) != fmt.Sprint(expFixed) {
+			t.Fatalf("located mismatched, exp: %v, got: %v", expFixed, located)
+		}
+
+		fixed, err := r.Correct(act)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(fixed) != fmt.Sprint(expFixed) {
+			t.Fatalf("fixed mismatched, exp: %v, got: %v", expFixed, fixed)
+		}
+		for j := range exp {
+			if !bytes.Equal(exp[j], act[j]) {
+				t.Fatalf("correct failed: %d+%d, vect: %d, size: %d", d, p, j, size)
+			}
+		}
+	}
+}
+
+func TestRS_CorrectTooMany(t *testing.T) {
+	d, p, size := 4, 1, 64 // Single parity can only detect errors.
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects := make([][]byte, d+p)
+	for j := range vects {
+		vects[j] = make([]byte, size)
+	}
+	for j := 0; j < d; j++ {
+		fillRandom(vects[j])
+	}
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects[1][7] ^= 1
+
+	bak := make([]byte, size)
+	copy(bak, vects[1])
+	_, err = r.Correct(vects)
+	if err != ErrTooManyCorrupted {
+		t.Fatalf("exp: %v, got: %v", ErrTooManyCorrupted, err)
+	}
+	if !bytes.Equal(bak, vects[1]) {
+		t.Fatal("vects should be unmodified on failure")
+	}
+}
+
+func BenchmarkRS_Correct(b *testing.B) {
+	d, p := 10, 4
+	size := 8 * kib
+
+	for _, n := range []int{0, 1, 2} {
+		b.Run(fmt.Sprintf("(%d+%d)-%s-corrupt_%d_vects-%s", d, p, byteToStr(size), n, featToStr(getCPUFeature())),
+			func(b *testing.B) {
+				exp := make([][]byte, d+p)
+				vects := make([][]byte, d+p)
+				for j := range exp {
+					exp[j], vects[j] = make([]byte, size), make([]byte, size)
+				}
+				for j := 0; j < d; j++ {
+					fillRandom(exp[j])
+				}
+				r, err := New(d, p)
+				if err != nil {
+					b.Fatal(err)
+				}
+				err = r.Encode(exp)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				b.SetBytes(int64((d + p) * size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					for j := range exp {
+						copy(vects[j], exp[j])
+					}
+					for j := 0; j < n; j++ {
+						vects[j][j] ^= 1
+					}
+					b.StartTimer()
+					_, err = r.Correct(vects)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultPolynomial is the default polynomial of GF(2^8): x^8+x^4+x^3+x^2+1.
+// Polynomials are written with the x^8 bit, so 0x11d here is 0x1d in gftbl.go.
+const DefaultPolynomial = 0x11d
+
+var ErrIllegalPolynomial = errors.New("illegal polynomial: must be an irreducible polynomial of degree 8")
+
+// galoisField is GF(2^8) defined by an irreducible polynomial.
+type galoisField struct {
+	poly       int
+	mulTbl     *[256][256]uint8
+	inverseTbl *[256]uint8
+	// For each c, 16 bytes of c*[0, 15] and 16 bytes of c*[0, 15]<<4,
+	// which are used by SIMD kernels.
+	lowHighTbl *[8192]uint8
+}
+
+// defaultGF uses the tables generated by mathtool/gentbls.
+var defaultGF = &galoisField{
+	poly:       DefaultPolynomial,
+	mulTbl:     &mulTbl,
+	inverseTbl: &inverseTbl,
+	lowHighTbl: &lowHighTbl,
+}
+
+var (
+	gfMu sync.Mutex
+	gfs  = map[int]*galoisField{DefaultPolynomial: defaultGF} // Fields made by now.
+)
+
+// getGaloisField returns the field of poly, tables are generated at the first time
+// and shared by all RS instances with the same polynomial.
+func getGaloisField(poly int) (f *galoisField, err error) {
+	if poly < 0x100 || poly > 0x1ff {
+		return nil, ErrIllegalPolynomial
+	}
+
+	gfMu.Lock()
+	defer gfMu.Unlock()
+
+	f, ok := gfs[poly]
+	if ok {
+		return f, nil
+	}
+	f, err = newGaloisField(poly)
+	if err != nil {
+		return nil, err
+	}
+	gfs[poly] = f
+	return f, nil
+}
+
+// newGaloisField generates tables of poly by polynomial multiplication,
+// so it doesn't need poly to be primitive. It returns ErrIllegalPolynomial
+// if poly is reducible (some elements have no inverse).
+func newGaloisField(poly int) (f *galoisField, err error) {
+	f = &galoisField{
+		poly:       poly,
+		mulTbl:     new([256][256]uint8),
+		inverseTbl: new([256]uint8),
+		lowHighTbl: new([8192]uint8),
+	}
+	for a := 0; a < 256; a++ {
+		for b := a; b < 256; b++ {
+			v := mulPoly(a, b, poly)
+			f.mulTbl[a][b], f.mulTbl[b][a] = v, v
+			if v == 1 {
+				f.inverseTbl[a], f.inverseTbl[b] = uint8(b), uint8(a)
+			}
+		}
+	}
+	for a := 1; a < 256; a++ {
+		if f.inverseTbl[a] == 0 {
+			return nil, ErrIllegalPolynomial
+		}
+	}
+	for c := 0; c < 256; c++ {
+		for j := 0; j < 16; j++ {
+			f.lowHighTbl[c*32+j] = f.mulTbl[c][j]
+			f.lowHighTbl[c*32+16+j] = f.mulTbl[c][j<<4]
+		}
+	}
+	return f, nil
+}
+
+// mulPoly returns a*b mod poly.
+func mulPoly(a, b, poly int) uint8 {
+	var v int
+	for b > 0 {
+		if b&1 == 1 {
+			v ^= a
+		}
+		a <<= 1
+		if a&0x100 != 0 {
+			a ^= poly
+		}
+		b >>= 1
+	}
+	return uint8(v)
+}
+
+// mul returns a*b.
+func (f *galoisField) mul(a, b uint8) uint8 {
+	return f.mulTbl[a][b]
+}
+
+// inv returns 1/a, inv(0) is 0.
+func (f *galoisField) inv(a uint8) uint8 {
+	return f.inverseTbl[a]
+}
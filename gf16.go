@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"sync"
+)
+
+// Polynomial16 is the primitive polynomial of GF(2^16): x^16+x^12+x^3+x+1,
+// which is also used by PAR2.
+const Polynomial16 = 0x1100b
+
+const gf16Order = 1<<16 - 1 // Number of non-zero elements.
+
+var (
+	gf16Once sync.Once
+	// gf16Exp[i] = x^i, it's doubled for avoiding modulo in gf16Mul.
+	gf16Exp *[2 * gf16Order]uint16
+	gf16Log *[1 << 16]uint16
+)
+
+// initGF16 generates log/exp tables of GF(2^16) (about 384 KiB),
+// it's called by New16.
+func initGF16() {
+	gf16Once.Do(func() {
+		exp := new([2 * gf16Order]uint16)
+		log := new([1 << 16]uint16)
+		v := 1
+		for i := 0; i < gf16Order; i++ {
+			exp[i], exp[i+gf16Order] = uint16(v), uint16(v)
+			log[v] = uint16(i)
+			v <<= 1
+			if v&(1<<16) != 0 {
+				v ^= Polynomial16
+			}
+		}
+		gf16Exp, gf16Log = exp, log
+	})
+}
+
+// gf16Mul returns a*b in GF(2^16).
+func gf16Mul(a, b uint16) uint16 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf16Exp[int(gf16Log[a])+int(gf16Log[b])]
+}
+
+// gf16Inv returns 1/a in GF(2^16), gf16Inv(0) is 0.
+func gf16Inv(a uint16) uint16 {
+	if a == 0 {
+		return 0
+	}
+	return gf16Exp[gf16Order-int(gf16Log[a])]
+}
+
+// mulTbl16 is the split tables of c: symbol x is split into 4 nibbles,
+// and c*x = T0[x&15] ^ T1[x>>4&15] ^ T2[x>>8&15] ^ T3[x>>12].
+// Each Tk has 16 low bytes then 16 high bytes of the products,
+// so SIMD kernels could look them up by byte shuffling.
+type mulTbl16 [128]byte
+
+func (t *mulTbl16) init(c uint16) {
+	for k := 0; k < 4; k++ {
+		var base [4]uint16 // Products of bits in nibble k, others are their sums.
+		for b := range base {
+			base[b] = gf16Mul(c, 1<<(4*uint(k)+uint(b)))
+		}
+		for n := 0; n < 16; n++ {
+			var v uint16
+			for b := range base {
+				if n&(1<<uint(b)) != 0 {
+					v ^= base[b]
+				}
+			}
+			t[k*32+n] = byte(v)
+			t[k*32+16+n] = byte(v >> 8)
+		}
+	}
+}
+
+// mulVect16NoSIMD computes output (^)= c*input, symbols are 16-bit little endian,
+// t is the split tables of c.
+func mulVect16NoSIMD(t *mulTbl16, input, output []byte, xor bool) {
+	for i := 0; i+1 < len(input); i += 2 {
+		n0, n1, n2, n3 := input[i]&15, input[i]>>4+32, input[i+1]&15+64, input[i+1]>>4+96
+		lo := t[n0] ^ t[n1] ^ t[n2] ^ t[n3]
+		hi := t[n0+16] ^ t[n1+16] ^ t[n2+16] ^ t[n3+16]
+		if xor {
+			output[i] ^= lo
+			output[i+1] ^= hi
+		} else {
+			output[i] = lo
+			output[i+1] = hi
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGF16(t *testing.T) {
+	initGF16()
+
+	// Checking with polynomial multiplication.
+	mul := func(a, b int) uint16 {
+		var v int
+		for b > 0 {
+			if b&1 == 1 {
+				v ^= a
+			}
+			a <<= 1
+			if a&(1<<16) != 0 {
+				a ^= Polynomial16
+			}
+			b >>= 1
+		}
+		return uint16(v)
+	}
+	r := newTestRand()
+	for i := 0; i < 1<<16; i++ {
+		a, b := uint16(r.Intn(1<<16)), uint16(r.Intn(1<<16))
+		if gf16Mul(a, b) != mul(int(a), int(b)) {
+			t.Fatalf("%d * %d mismatched", a, b)
+		}
+	}
+	for a := 1; a < 1<<16; a++ {
+		if gf16Mul(uint16(a), gf16Inv(uint16(a))) != 1 {
+			t.Fatalf("%d * its inverse should be 1", a)
+		}
+	}
+	if gf16Mul(0, 3) != 0 || gf16Inv(0) != 0 {
+		t.Fatal("multiplying 0 or inverting 0 should be 0")
+	}
+}
+
+func TestGMU16(t *testing.T) {
+	initGF16()
+
+	feats := []int{featNoSIMD}
+	if getCPUFeature() == featAVX2 {
+		feats = append(feats, featAVX2)
+	}
+	r := newTestRand()
+	for _, feat := range feats {
+		for size := 2; size <= 1024; size += 2 {
+			c := uint16(r.Intn(1 << 16))
+			input := make([]byte, size)
+			fillRandom(input)
+			exp := make([]byte, size)
+			for i := 0; i < size; i += 2 {
+				v := gf16Mul(c, uint16(input[i])|uint16(input[i+1])<<8)
+				exp[i], exp[i+1] = byte(v), byte(v>>8)
+			}
+
+			var tbl mulTbl16
+			tbl.init(c)
+			act := make([]byte, size)
+			mulVect16(feat, &tbl, input, act, false)
+			if !bytes.Equal(exp, act) {
+				t.Fatalf("%s mismatched, size: %d", featToStr(feat), size)
+			}
+			mulVect16(feat, &tbl, input, act, true) // act ^= c*input, should be 0.
+			if !bytes.Equal(make([]byte, size), act) {
+				t.Fatalf("%s xor mismatched, size: %d", featToStr(feat), size)
+			}
+		}
+	}
+}
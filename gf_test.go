@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"testing"
+)
+
+// Primitive polynomials of degree 8, same as the output of mathtool/gentbls.
+var primitivePolynomials = []int{
+	0x11d, 0x12b, 0x12d, 0x14d, 0x15f, 0x163, 0x165, 0x169,
+	0x171, 0x187, 0x18d, 0x1a9, 0x1c3, 0x1cf, 0x1e7, 0x1f5,
+}
+
+func TestNewGaloisField(t *testing.T) {
+	f, err := newGaloisField(DefaultPolynomial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *f.mulTbl != mulTbl || *f.inverseTbl != inverseTbl || *f.lowHighTbl != lowHighTbl {
+		t.Fatal("generated tables mismatched with gftbl.go")
+	}
+
+	for _, poly := range primitivePolynomials {
+		f, err := getGaloisField(poly)
+		if err != nil {
+			t.Fatalf("polynomial: %#x, %v", poly, err)
+		}
+		// x (2) is a generator of primitive polynomial's field.
+		var e uint8 = 1
+		for i := 1; i <= 255; i++ {
+			e = f.mul(e, 2)
+			if e == 1 && i != 255 {
+				t.Fatalf("polynomial: %#x isn't primitive, order of x: %d", poly, i)
+			}
+		}
+		if e != 1 {
+			t.Fatalf("polynomial: %#x, x^255 should be 1", poly)
+		}
+		for a := 1; a < 256; a++ {
+			if f.mul(uint8(a), f.inv(uint8(a))) != 1 {
+				t.Fatalf("polynomial: %#x, %d * its inverse should be 1", poly, a)
+			}
+		}
+	}
+
+	f, err = getGaloisField(0x11b) // Irreducible but not primitive (AES).
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.mul(0x57, 0x83) != 0xc1 { // Example in FIPS-197.
+		t.Fatal("0x57 * 0x83 should be 0xc1 in AES field")
+	}
+	f2, err := getGaloisField(0x11b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f != f2 {
+		t.Fatal("field should be shared")
+	}
+
+	for _, poly := range []int{0, 0x1d, 0x100, 0x11c, 0x111, 0x200} {
+		_, err = getGaloisField(poly)
+		if err != ErrIllegalPolynomial {
+			t.Fatalf("polynomial: %#x, exp: %v, got: %v", poly, ErrIllegalPolynomial, err)
+		}
+	}
+}
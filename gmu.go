@@ -2,27 +2,24 @@ package reedsolomon
 
 // gmu is the Galois-field multiply unit.
 type gmu struct {
+	gf *galoisField
+
 	// output = c * input
 	mulVect func(c byte, input, output []byte)
 	// output ^= c * input
 	mulVectXOR func(c byte, input, output []byte)
 }
 
-func mulVectNoSIMD(c byte, input, output []byte) {
-	t := mulTbl[c][:256]
+func (g *gmu) mulVectNoSIMD(c byte, input, output []byte) {
+	t := g.gf.mulTbl[c][:256]
 	for i := 0; i < len(input); i++ {
 		output[i] = t[input[i]]
 	}
 }
 
-func mulVectXORNoSIMD(c byte, input, output []byte) {
-	t := mulTbl[c][:256]
+func (g *gmu) mulVectXORNoSIMD(c byte, input, output []byte) {
+	t := g.gf.mulTbl[c][:256]
 	for i := 0; i < len(input); i++ {
 		output[i] ^= t[input[i]]
 	}
 }
-
-// gfMul returns a*b in GF(2^8).
-func gfMul(a, b uint8) uint8 {
-	return mulTbl[a][b]
-}
@@ -0,0 +1,29 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+// mulVect16 computes output (^)= c*input in GF(2^16),
+// t is the split tables of c, len(input) must be even.
+func mulVect16(feat int, t *mulTbl16, input, output []byte, xor bool) {
+	if feat == featAVX2 {
+		n := len(input) &^ 31
+		if n > 0 {
+			if xor {
+				mulVectXOR16AVX2(t, input[:n], output[:n])
+			} else {
+				mulVect16AVX2(t, input[:n], output[:n])
+			}
+			input, output = input[n:], output[n:]
+		}
+	}
+	mulVect16NoSIMD(t, input, output, xor)
+}
+
+//go:noescape
+func mulVect16AVX2(tbl *mulTbl16, input, output []byte)
+
+//go:noescape
+func mulVectXOR16AVX2(tbl *mulTbl16, input, output []byte)
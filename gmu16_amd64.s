@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+// Multiplication in GF(2^16) with split tables (see mulTbl16).
+// Every 16-bit symbol is split into 4 nibbles (in the low bytes of words),
+// and products of low/high bytes are looked up by VPSHUFB separately.
+// len(input) must be a multiple of 32.
+
+#define low0  Y0
+#define high0 Y1
+#define low1  Y2
+#define high1 Y3
+#define low2  Y4
+#define high2 Y5
+#define low3  Y6
+#define high3 Y7
+#define mask  Y8
+#define sym   Y9
+#define nib   Y10
+#define lo    Y11
+#define hi    Y12
+#define tmp   Y13
+
+#define tbl_ptr AX
+#define in      BX
+#define len     CX
+#define out     DI
+
+// func mulVect16AVX2(tbl *mulTbl16, input, output []byte)
+TEXT ·mulVect16AVX2(SB), 4, $0-56
+	MOVQ tbl+0(FP), tbl_ptr
+	MOVQ input_base+8(FP), in
+	MOVQ input_len+16(FP), len
+	MOVQ output_base+32(FP), out
+	VBROADCASTI128 (tbl_ptr), low0
+	VBROADCASTI128 16(tbl_ptr), high0
+	VBROADCASTI128 32(tbl_ptr), low1
+	VBROADCASTI128 48(tbl_ptr), high1
+	VBROADCASTI128 64(tbl_ptr), low2
+	VBROADCASTI128 80(tbl_ptr), high2
+	VBROADCASTI128 96(tbl_ptr), low3
+	VBROADCASTI128 112(tbl_ptr), high3
+	VPCMPEQW Y8, Y8, mask
+	VPSRLW   $12, mask, mask // 0x000f in every word.
+	TESTQ    len, len
+	JZ       done
+
+loop:
+	VMOVDQU (in), sym
+
+	// Nibble 0.
+	VPAND   mask, sym, nib
+	VPSHUFB nib, low0, lo
+	VPSHUFB nib, high0, hi
+
+	// Nibble 1.
+	VPSRLW  $4, sym, nib
+	VPAND   mask, nib, nib
+	VPSHUFB nib, low1, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high1, tmp
+	VPXOR   tmp, hi, hi
+
+	// Nibble 2.
+	VPSRLW  $8, sym, nib
+	VPAND   mask, nib, nib
+	VPSHUFB nib, low2, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high2, tmp
+	VPXOR   tmp, hi, hi
+
+	// Nibble 3.
+	VPSRLW  $12, sym, nib
+	VPSHUFB nib, low3, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high3, tmp
+	VPXOR   tmp, hi, hi
+
+	// High bytes of products go to the high bytes of words.
+	VPSLLW  $8, hi, hi
+	VPXOR   hi, lo, lo
+	VMOVDQU lo, (out)
+
+	ADDQ $32, in
+	ADDQ $32, out
+	SUBQ $32, len
+	JNZ  loop
+
+done:
+	VZEROUPPER
+	RET
+
+// func mulVectXOR16AVX2(tbl *mulTbl16, input, output []byte)
+TEXT ·mulVectXOR16AVX2(SB), 4, $0-56
+	MOVQ tbl+0(FP), tbl_ptr
+	MOVQ input_base+8(FP), in
+	MOVQ input_len+16(FP), len
+	MOVQ output_base+32(FP), out
+	VBROADCASTI128 (tbl_ptr), low0
+	VBROADCASTI128 16(tbl_ptr), high0
+	VBROADCASTI128 32(tbl_ptr), low1
+	VBROADCASTI128 48(tbl_ptr), high1
+	VBROADCASTI128 64(tbl_ptr), low2
+	VBROADCASTI128 80(tbl_ptr), high2
+	VBROADCASTI128 96(tbl_ptr), low3
+	VBROADCASTI128 112(tbl_ptr), high3
+	VPCMPEQW Y8, Y8, mask
+	VPSRLW   $12, mask, mask // 0x000f in every word.
+	TESTQ    len, len
+	JZ       done_xor
+
+loop_xor:
+	VMOVDQU (in), sym
+
+	// Nibble 0.
+	VPAND   mask, sym, nib
+	VPSHUFB nib, low0, lo
+	VPSHUFB nib, high0, hi
+
+	// Nibble 1.
+	VPSRLW  $4, sym, nib
+	VPAND   mask, nib, nib
+	VPSHUFB nib, low1, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high1, tmp
+	VPXOR   tmp, hi, hi
+
+	// Nibble 2.
+	VPSRLW  $8, sym, nib
+	VPAND   mask, nib, nib
+	VPSHUFB nib, low2, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high2, tmp
+	VPXOR   tmp, hi, hi
+
+	// Nibble 3.
+	VPSRLW  $12, sym, nib
+	VPSHUFB nib, low3, tmp
+	VPXOR   tmp, lo, lo
+	VPSHUFB nib, high3, tmp
+	VPXOR   tmp, hi, hi
+
+	// High bytes of products go to the high bytes of words.
+	VPSLLW  $8, hi, hi
+	VPXOR   hi, lo, lo
+	VPXOR   (out), lo, lo
+	VMOVDQU lo, (out)
+
+	ADDQ $32, in
+	ADDQ $32, out
+	SUBQ $32, len
+	JNZ  loop_xor
+
+done_xor:
+	VZEROUPPER
+	RET
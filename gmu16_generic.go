@@ -0,0 +1,10 @@
+//go:build !amd64
+// +build !amd64
+
+package reedsolomon
+
+// mulVect16 computes output (^)= c*input in GF(2^16),
+// t is the split tables of c, len(input) must be even.
+func mulVect16(feat int, t *mulTbl16, input, output []byte, xor bool) {
+	mulVect16NoSIMD(t, input, output, xor)
+}
@@ -8,21 +8,21 @@ package reedsolomon
 func (g *gmu) initFunc(feat int) {
 	switch feat {
 	case featAVX2:
-		g.mulVect = mulVectAVX2C
-		g.mulVectXOR = mulVectXORAVX2C
+		g.mulVect = g.mulVectAVX2C
+		g.mulVectXOR = g.mulVectXORAVX2C
 	default:
-		g.mulVect = mulVectNoSIMD
-		g.mulVectXOR = mulVectXORNoSIMD
+		g.mulVect = g.mulVectNoSIMD
+		g.mulVectXOR = g.mulVectXORNoSIMD
 	}
 }
 
-func mulVectAVX2C(c byte, input, output []byte) {
-	tbl := lowHighTbl[int(c)*32 : int(c)*32+32]
+func (g *gmu) mulVectAVX2C(c byte, input, output []byte) {
+	tbl := g.gf.lowHighTbl[int(c)*32 : int(c)*32+32]
 	mulVectAVX2(tbl, input, output)
 }
 
-func mulVectXORAVX2C(c byte, input, output []byte) {
-	tbl := lowHighTbl[int(c)*32 : int(c)*32+32]
+func (g *gmu) mulVectXORAVX2C(c byte, input, output []byte) {
+	tbl := g.gf.lowHighTbl[int(c)*32 : int(c)*32+32]
 	mulVectXORAVX2(tbl, input, output)
 }
 
@@ -4,6 +4,6 @@
 package reedsolomon
 
 func (g *gmu) initFunc(feat int) {
-	g.mulVect = mulVectNoSIMD
-	g.mulVectXOR = mulVectXORNoSIMD
+	g.mulVect = g.mulVectNoSIMD
+	g.mulVectXOR = g.mulVectXORNoSIMD
 }
@@ -15,13 +15,20 @@ func TestGMU(t *testing.T) {
 
 	switch getCPUFeature() {
 	case featAVX2:
-		testGMU(t, maxSize, featAVX2, featNoSIMD)
+		testGMU(t, defaultGF, maxSize, featAVX2, featNoSIMD)
+		for _, poly := range []int{0x11b, 0x187} { // Alternate tables.
+			f, err := getGaloisField(poly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testGMU(t, f, maxSize, featAVX2, featNoSIMD)
+		}
 	default:
 		t.Logf("no SIMD feature detected, skip comparing encoding results with no-SIMD implementation")
 	}
 }
 
-func testGMU(t *testing.T, maxSize, feat, cmpFeat int) {
+func testGMU(t *testing.T, f *galoisField, maxSize, feat, cmpFeat int) {
 	fs := featToStr(feat)
 
 	start, n := 1, 1
@@ -29,10 +36,10 @@ func testGMU(t *testing.T, maxSize, feat, cmpFeat int) {
 		start, n = 16, 16 // The min size for SIMD instructions.
 	}
 
-	g := new(gmu)
+	g := &gmu{gf: f}
 	g.initFunc(feat)
 
-	cg := new(gmu)
+	cg := &gmu{gf: f}
 	cg.initFunc(cmpFeat)
 
 	for size := start; size <= maxSize; size += n {
@@ -58,6 +65,6 @@ func testGMU(t *testing.T, maxSize, feat, cmpFeat int) {
 		}
 	}
 
-	t.Logf("%s passed, size: [%d, %d), size = i * %d",
-		fs, start, maxSize+1, n)
+	t.Logf("%s passed, polynomial: %#x, size: [%d, %d), size = i * %d",
+		fs, f.poly, start, maxSize+1, n)
 }
@@ -0,0 +1,200 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// inverseCache is a cache of inverse matrices bounded by total bytes,
+// it's safe for concurrent use. Matrices are keyed by codec layout
+// (see codecLayout) and survived vectors, so one cache could be shared by
+// RS instances with different layouts.
+//
+// It uses CLOCK replacement (an approximation of LRU): a hit only marks
+// its entry as referenced under a read lock, and when the cache is full
+// the clock hand sweeps entries, clearing referenced marks until it finds
+// an unreferenced one to evict. So hot failure patterns stay in the cache,
+// and stale ones are replaced by new ones.
+type inverseCache struct {
+	// Counters are accessed atomically, keep them 64-bit aligned.
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	mu       sync.RWMutex
+	index    map[inverseCacheEntryKey]int // Key -> index of slots.
+	slots    []inverseCacheSlot           // Grows on demand, em is nil if it's free.
+	free     []int                        // Indexes of free slots.
+	hand     int                          // Clock hand, index of the next slot to check.
+	bytes    int                          // Total size of cached matrices.
+	maxBytes int
+}
+
+type inverseCacheEntryKey struct {
+	layout   uint32
+	survived inverseCacheKey
+}
+
+type inverseCacheSlot struct {
+	key inverseCacheEntryKey
+	em  matrix
+	ref uint32 // 1 if accessed since the clock hand passed, accessed atomically.
+}
+
+// InverseCacheStats is the statistics of the inverse matrix cache.
+// For the shared cache (see SetInverseCacheBudget), it's the statistics
+// of all RS instances using it.
+type InverseCacheStats struct {
+	Hits      uint64 // Number of lookups found in the cache.
+	Misses    uint64 // Number of lookups not found (matrix inverted).
+	Evictions uint64 // Number of matrices evicted for making room.
+	Len       int    // Number of cached matrices.
+	Bytes     int    // Total size of cached matrices.
+	MaxBytes  int    // Max total size of cached matrices.
+}
+
+func newInverseCache(maxBytes int) *inverseCache {
+	return &inverseCache{
+		index:    make(map[inverseCacheEntryKey]int),
+		maxBytes: maxBytes,
+	}
+}
+
+// get returns the cached matrix of key, and marks it referenced.
+func (c *inverseCache) get(layout uint32, key inverseCacheKey) (em matrix, ok bool) {
+	c.mu.RLock()
+	i, ok := c.index[inverseCacheEntryKey{layout, key}]
+	if ok {
+		s := &c.slots[i]
+		if atomic.LoadUint32(&s.ref) == 0 {
+			atomic.StoreUint32(&s.ref, 1)
+		}
+		em = s.em
+	}
+	c.mu.RUnlock()
+
+	if ok {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+	return
+}
+
+// contains returns true if key is in the cache, it doesn't affect
+// the replacement and statistics.
+func (c *inverseCache) contains(layout uint32, key inverseCacheKey) bool {
+	c.mu.RLock()
+	_, ok := c.index[inverseCacheEntryKey{layout, key}]
+	c.mu.RUnlock()
+	return ok
+}
+
+// add adds em into the cache, evicting unreferenced matrices if it's full.
+func (c *inverseCache) add(layout uint32, key inverseCacheKey, em matrix) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := inverseCacheEntryKey{layout, key}
+	if _, ok := c.index[k]; ok { // Added by another goroutine.
+		return
+	}
+	if len(em) > c.maxBytes {
+		return
+	}
+	c.evict(c.maxBytes - len(em))
+
+	var i int
+	if n := len(c.free); n > 0 {
+		i = c.free[n-1]
+		c.free = c.free[:n-1]
+		c.slots[i] = inverseCacheSlot{key: k, em: em} // Not referenced until next hit.
+	} else {
+		i = len(c.slots)
+		c.slots = append(c.slots, inverseCacheSlot{key: k, em: em})
+	}
+	c.index[k] = i
+	c.bytes += len(em)
+}
+
+// evict evicts matrices until total size <= maxBytes.
+// It must be called with c.mu locked.
+func (c *inverseCache) evict(maxBytes int) {
+	for c.bytes > maxBytes {
+		if c.hand >= len(c.slots) {
+			c.hand = 0
+		}
+		s := &c.slots[c.hand]
+		c.hand++
+		if s.em == nil {
+			continue
+		}
+		if atomic.LoadUint32(&s.ref) == 1 {
+			atomic.StoreUint32(&s.ref, 0) // Second chance.
+			continue
+		}
+		delete(c.index, s.key)
+		c.bytes -= len(s.em)
+		*s = inverseCacheSlot{}
+		c.free = append(c.free, c.hand-1)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// setMaxBytes changes the max total size, evicting matrices if needed.
+func (c *inverseCache) setMaxBytes(n int) {
+	if n < 0 {
+		n = 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxBytes = n
+	c.evict(n)
+}
+
+// export returns cached matrices of layout.
+func (c *inverseCache) export(layout uint32) (keys []inverseCacheKey, ems []matrix) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for i := range c.slots {
+		s := &c.slots[i]
+		if s.em != nil && s.key.layout == layout {
+			keys = append(keys, s.key.survived)
+			ems = append(ems, s.em) // Cached matrices are never modified.
+		}
+	}
+	return
+}
+
+func (c *inverseCache) stats() InverseCacheStats {
+	c.mu.RLock()
+	n, bytes, maxBytes := len(c.index), c.bytes, c.maxBytes
+	c.mu.RUnlock()
+
+	return InverseCacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Len:       n,
+		Bytes:     bytes,
+		MaxBytes:  maxBytes,
+	}
+}
+
+// inverseCacheKey is the bitmap of survived vectors,
+// it's wide enough for all legal configurations (up to maxVects vectors).
+type inverseCacheKey [maxVects / 64]uint64
+
+func makeInverseCacheKey(survived []int) (key inverseCacheKey) {
+	for _, i := range survived {
+		key[i>>6] |= 1 << uint(i&63)
+	}
+	return
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMakeInverseCacheKey(t *testing.T) {
+
+	type tc struct {
+		survived []int
+		exp      inverseCacheKey
+	}
+	cases := []tc{
+		{[]int{0}, inverseCacheKey{1}},
+		{[]int{1}, inverseCacheKey{2}},
+		{[]int{0, 1}, inverseCacheKey{3}},
+		{[]int{0, 1, 2}, inverseCacheKey{7}},
+		{[]int{0, 2}, inverseCacheKey{5}},
+		{[]int{63, 64}, inverseCacheKey{1 << 63, 1}},
+		{[]int{1, 130, 255}, inverseCacheKey{2, 0, 4, 1 << 63}},
+	}
+	survived := make([]int, 64)
+	for i := range survived {
+		survived[i] = i
+	}
+	cases = append(cases, tc{survived, inverseCacheKey{math.MaxUint64}})
+	survived = make([]int, maxVects)
+	for i := range survived {
+		survived[i] = i
+	}
+	cases = append(cases, tc{survived, inverseCacheKey{math.MaxUint64, math.MaxUint64, math.MaxUint64, math.MaxUint64}})
+	for i, c := range cases {
+		got := makeInverseCacheKey(c.survived)
+		if got != c.exp {
+			t.Fatalf("case: %d, exp: %v, got: %v, survived: %#v", i, c.exp, got, c.survived)
+		}
+	}
+}
+
+func TestInverseCache_Clock(t *testing.T) {
+	c := newInverseCache(3)
+	keys := make([]inverseCacheKey, 5)
+	for i := range keys {
+		keys[i] = makeInverseCacheKey([]int{i})
+	}
+
+	for i := 0; i < 3; i++ {
+		c.add(0, keys[i], matrix{byte(i)})
+	}
+	c.add(0, keys[0], matrix{0xff}) // Duplicated add is ignored.
+	if em, ok := c.get(0, keys[0]); !ok || em[0] != 0 {
+		t.Fatal("cached matrix mismatched")
+	}
+
+	// keys[0] is referenced, so keys[1] is evicted.
+	c.add(0, keys[3], matrix{3})
+	if _, ok := c.get(0, keys[1]); ok {
+		t.Fatal("unreferenced matrix should be evicted")
+	}
+	for _, i := range []int{0, 2, 3} {
+		if em, ok := c.get(0, keys[i]); !ok || em[0] != byte(i) {
+			t.Fatalf("matrix %d should be cached", i)
+		}
+	}
+
+	// All referenced, the clock hand clears all marks and evicts the next one.
+	c.add(0, keys[4], matrix{4})
+	if _, ok := c.get(0, keys[2]); ok {
+		t.Fatal("matrix 2 should be evicted")
+	}
+
+	exp := InverseCacheStats{Hits: 4, Misses: 2, Evictions: 2, Len: 3, Bytes: 3, MaxBytes: 3}
+	if act := c.stats(); act != exp {
+		t.Fatalf("stats mismatched, exp: %+v, got: %+v", exp, act)
+	}
+	if len(c.index)+len(c.free) != len(c.slots) {
+		t.Fatal("index mismatched with slots")
+	}
+	for k, i := range c.index {
+		if c.slots[i].key != k {
+			t.Fatal("index mismatched with slots")
+		}
+	}
+}
+
+// Hot failure patterns must survive a storm of one-off patterns.
+func TestRS_InverseCacheHot(t *testing.T) {
+	d, p := 10, 4
+	r, err := New(d, p, WithInverseCacheBytes(d*d*8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rand.Seed(time.Now().UnixNano())
+
+	hot, _ := genIdxForTest(d, p, d, p)
+	for i := 0; i < 256; i++ {
+		survived, _ := genIdxForTest(d, p, d, p)
+		if _, err = r.getEncMatrixForReconst(survived); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = r.getEncMatrixForReconst(hot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := r.inverseCache.index[inverseCacheEntryKey{0, makeInverseCacheKey(hot)}]; !ok {
+		t.Fatal("hot matrix is evicted")
+	}
+
+	s := r.InverseCacheStats()
+	if s.Hits+s.Misses != 512 || s.Evictions == 0 || s.Len != 8 || s.Bytes != 8*d*d {
+		t.Fatalf("stats mismatched: %+v", s)
+	}
+
+	r, err = New(d, p, WithInverseCacheBytes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r.InverseCacheStats(); s != (InverseCacheStats{}) {
+		t.Fatalf("stats should be zero if cache is disabled: %+v", s)
+	}
+}
+
+func TestRS_InverseCacheConcurrent(t *testing.T) {
+	d, p := 10, 4
+	r, err := New(d, p, WithInverseCacheBytes(d*d*4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 128; i++ {
+				survived, _ := genIdxForTest(d, p, d, p)
+				act, err := r.getEncMatrixForReconst(survived)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				exp, err := r.encMatrix.makeEncMatrixForReconst(r.gf, survived)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !bytes.Equal(act, exp) {
+					t.Error("cache matrix mismatched")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := r.InverseCacheStats()
+	if s.Hits+s.Misses != 8*128 || s.Bytes > s.MaxBytes {
+		t.Fatalf("stats mismatched: %+v", s)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/bits"
+)
+
+// Inverse cache file format (version 1), all integers are little endian:
+//
+//	0:4   magic "RSIC"
+//	4     version
+//	5     matrix type
+//	6:8   DataNum
+//	8:10  ParityNum
+//	10:12 polynomial of GF(2^8)
+//	12:16 CRC32C of the encoding matrix
+//	16:20 number of entries
+//	20:24 CRC32C of header[0:20]
+//
+// Header is followed by entries, each entry is the survived bitmap
+// (4 uint64, see inverseCacheKey), the DataNum*DataNum inverse matrix
+// and the CRC32C of them.
+const (
+	InverseCacheFormatVersion = 1
+	inverseCacheMagic         = "RSIC"
+	inverseCacheHeaderSize    = 24
+	inverseCacheKeySize       = maxVects / 8
+	inverseCacheCRCSize       = 4 // CRC32C of each entry.
+)
+
+var (
+	ErrNotInverseCache      = errors.New("not an inverse cache file")
+	ErrInverseCacheVersion  = errors.New("unsupported inverse cache file version")
+	ErrInverseCacheChecksum = errors.New("inverse cache checksum mismatch")
+	ErrInverseCacheMismatch = errors.New("inverse cache mismatch")
+	ErrInverseCacheDisabled = errors.New("inverse cache disabled")
+)
+
+// ExportInverseCache writes cached inverse matrices into w, which could be
+// loaded by ImportInverseCache (e.g. after restarting) for avoiding
+// matrix inversion of failure patterns seen before.
+func (r *RS) ExportInverseCache(w io.Writer) (err error) {
+	if r.inverseCache == nil {
+		return ErrInverseCacheDisabled
+	}
+
+	keys, ems := r.inverseCache.export(r.layout)
+
+	b := make([]byte, inverseCacheHeaderSize)
+	r.marshalInverseCacheHeader(b, len(keys))
+	_, err = w.Write(b)
+	if err != nil {
+		return
+	}
+
+	d := r.DataNum
+	b = make([]byte, inverseCacheKeySize+d*d+inverseCacheCRCSize)
+	for i, key := range keys {
+		for j, v := range key {
+			binary.LittleEndian.PutUint64(b[j*8:], v)
+		}
+		copy(b[inverseCacheKeySize:], ems[i])
+		n := len(b) - inverseCacheCRCSize
+		binary.LittleEndian.PutUint32(b[n:], crc32.Checksum(b[:n], crc32cTbl))
+		_, err = w.Write(b)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ImportInverseCache reads inverse matrices written by ExportInverseCache
+// from rd into the cache. The file must be exported by an RS with the same
+// DataNum, ParityNum and encoding matrix, otherwise it returns an error
+// wrapping ErrInverseCacheMismatch.
+// If there are more matrices than the cache could hold, the earlier ones are evicted.
+func (r *RS) ImportInverseCache(rd io.Reader) (err error) {
+	if r.inverseCache == nil {
+		return ErrInverseCacheDisabled
+	}
+
+	b := make([]byte, inverseCacheHeaderSize)
+	_, err = io.ReadFull(rd, b)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return ErrNotInverseCache
+		}
+		return
+	}
+	n, err := r.unmarshalInverseCacheHeader(b)
+	if err != nil {
+		return
+	}
+
+	d, p := r.DataNum, r.ParityNum
+	b = make([]byte, inverseCacheKeySize+d*d+inverseCacheCRCSize)
+	for i := 0; i < n; i++ {
+		_, err = io.ReadFull(rd, b)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return
+		}
+		m := len(b) - inverseCacheCRCSize
+		if binary.LittleEndian.Uint32(b[m:]) != crc32.Checksum(b[:m], crc32cTbl) {
+			return fmt.Errorf("%w: entry %d", ErrInverseCacheChecksum, i)
+		}
+
+		var key inverseCacheKey
+		cnt := 0
+		for j := range key {
+			key[j] = binary.LittleEndian.Uint64(b[j*8:])
+			cnt += bits.OnesCount64(key[j])
+		}
+		if cnt != d || key != key.trim(d+p) {
+			return fmt.Errorf("%w: entry %d has illegal survived vectors", ErrInverseCacheMismatch, i)
+		}
+		em := make(matrix, d*d)
+		copy(em, b[inverseCacheKeySize:])
+		r.inverseCache.add(r.layout, key, em)
+	}
+	return nil
+}
+
+// Warmup computes and caches inverse matrices for expected failure patterns.
+// Each pattern is a set of lost vector indexes, and the matrix is made as
+// Reconst does when all the other vectors survive.
+// Patterns which lose no data vector don't need inverse matrices.
+func (r *RS) Warmup(patterns [][]int) (err error) {
+	if r.inverseCache == nil {
+		return ErrInverseCacheDisabled
+	}
+
+	d, p := r.DataNum, r.ParityNum
+	survived := make([]int, 0, d+p)
+	for _, lost := range patterns {
+		if err = checkVectIdx(lost, d, p); err != nil {
+			return
+		}
+		if len(lost) > p {
+			return ErrTooManyLost
+		}
+		survived = survived[:0]
+		for i := 0; i < d+p && len(survived) < d; i++ {
+			if !isLost(i, lost) {
+				survived = append(survived, i)
+			}
+		}
+		if survived[d-1] < d { // All data vectors survived.
+			continue
+		}
+		key := makeInverseCacheKey(survived)
+		if r.inverseCache.contains(r.layout, key) {
+			continue
+		}
+		em, err := r.makeEncMatrixForReconst(survived)
+		if err != nil {
+			return err
+		}
+		r.inverseCache.add(r.layout, key, em)
+	}
+	return nil
+}
+
+func isLost(i int, lost []int) bool {
+	for _, v := range lost {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// trim returns key without bits of vectors >= n.
+func (key inverseCacheKey) trim(n int) inverseCacheKey {
+	for i := range key {
+		if n <= i*64 {
+			key[i] = 0
+		} else if n < (i+1)*64 {
+			key[i] &= 1<<uint(n-i*64) - 1
+		}
+	}
+	return key
+}
+
+func (r *RS) marshalInverseCacheHeader(b []byte, n int) {
+	copy(b[0:4], inverseCacheMagic)
+	b[4] = InverseCacheFormatVersion
+	b[5] = byte(r.matrixType)
+	binary.LittleEndian.PutUint16(b[6:8], uint16(r.DataNum))
+	binary.LittleEndian.PutUint16(b[8:10], uint16(r.ParityNum))
+	binary.LittleEndian.PutUint16(b[10:12], uint16(r.gf.poly))
+	binary.LittleEndian.PutUint32(b[12:16], r.MatrixChecksum())
+	binary.LittleEndian.PutUint32(b[16:20], uint32(n))
+	binary.LittleEndian.PutUint32(b[20:24], crc32.Checksum(b[:20], crc32cTbl))
+}
+
+// unmarshalInverseCacheHeader checks the header made by marshalInverseCacheHeader,
+// and returns the number of entries.
+func (r *RS) unmarshalInverseCacheHeader(b []byte) (n int, err error) {
+	if string(b[0:4]) != inverseCacheMagic {
+		return 0, ErrNotInverseCache
+	}
+	if binary.LittleEndian.Uint32(b[20:24]) != crc32.Checksum(b[:20], crc32cTbl) {
+		return 0, fmt.Errorf("%w: header", ErrInverseCacheChecksum)
+	}
+	if b[4] != InverseCacheFormatVersion {
+		return 0, ErrInverseCacheVersion
+	}
+	mt := MatrixType(b[5])
+	d := int(binary.LittleEndian.Uint16(b[6:8]))
+	p := int(binary.LittleEndian.Uint16(b[8:10]))
+	poly := int(binary.LittleEndian.Uint16(b[10:12]))
+	if d != r.DataNum || p != r.ParityNum || mt != r.matrixType || poly != r.gf.poly ||
+		binary.LittleEndian.Uint32(b[12:16]) != r.MatrixChecksum() {
+		return 0, fmt.Errorf("%w: cache is %d+%d (matrix: %d, polynomial: %#x), codec is %d+%d (matrix: %d, polynomial: %#x)",
+			ErrInverseCacheMismatch, d, p, mt, poly, r.DataNum, r.ParityNum, r.matrixType, r.gf.poly)
+	}
+	return int(binary.LittleEndian.Uint32(b[16:20])), nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestRS_ExportImportInverseCache(t *testing.T) {
+	for _, dp := range [][]int{{testDataNum, testParityNum}, {100, 28}} {
+		testExportImportInverseCache(t, dp[0], dp[1])
+	}
+}
+
+func testExportImportInverseCache(t *testing.T, d, p int) {
+	r, err := New(d, p, WithInverseCacheBytes(mib))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 16; i++ {
+		survived, _ := genIdxForTest(d, p, d, p)
+		if _, err = r.getEncMatrixForReconst(survived); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	err = r.ExportInverseCache(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := New(d, p, WithInverseCacheBytes(mib))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r2.ImportInverseCache(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r2.inverseCache.index) != len(r.inverseCache.index) {
+		t.Fatalf("(%d+%d): imported %d matrices, exp: %d",
+			d, p, len(r2.inverseCache.index), len(r.inverseCache.index))
+	}
+	for _, s := range r.inverseCache.slots {
+		em, ok := r2.inverseCache.get(0, s.key.survived)
+		if !ok || !bytes.Equal(em, s.em) {
+			t.Fatalf("(%d+%d): imported matrix mismatched", d, p)
+		}
+	}
+
+	// Smaller cache keeps the last matrices.
+	r3, err := New(d, p, WithInverseCacheBytes(d*d*2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r3.ImportInverseCache(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r3.InverseCacheStats(); s.Len != 2 || s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("(%d+%d): stats mismatched: %+v", d, p, s)
+	}
+}
+
+func TestRS_ImportInverseCacheIllegal(t *testing.T) {
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Warmup([][]int{{0}, {1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	err = r.ExportInverseCache(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+
+	corrupt := func(i int) []byte {
+		c := make([]byte, len(b))
+		copy(c, b)
+		c[i] ^= 1
+		return c
+	}
+
+	vr, err := New(d, p, WithMatrix(VandermondeMatrix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := New(d, p, WithPolynomial(0x12b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pn, err := New(d, p+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disabled, err := New(d, p, WithInverseCacheBytes(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		r   *RS
+		b   []byte
+		exp error
+	}{
+		{r, nil, ErrNotInverseCache},
+		{r, []byte("RSSF0123456789abcdef0123"), ErrNotInverseCache},
+		{r, corrupt(5), ErrInverseCacheChecksum},
+		{r, corrupt(inverseCacheHeaderSize + 40), ErrInverseCacheChecksum},
+		{r, b[:len(b)-1], io.ErrUnexpectedEOF},
+		{vr, b, ErrInverseCacheMismatch},
+		{pr, b, ErrInverseCacheMismatch},
+		{pn, b, ErrInverseCacheMismatch},
+		{disabled, b, ErrInverseCacheDisabled},
+	}
+	for i, c := range cases {
+		err = c.r.ImportInverseCache(bytes.NewReader(c.b))
+		if !errors.Is(err, c.exp) {
+			t.Fatalf("case: %d, exp: %v, got: %v", i, c.exp, err)
+		}
+	}
+
+	if err = disabled.ExportInverseCache(buf); err != ErrInverseCacheDisabled {
+		t.Fatalf("exp: %v, got: %v", ErrInverseCacheDisabled, err)
+	}
+}
+
+func TestRS_Warmup(t *testing.T) {
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p, WithInverseCacheBytes(mib))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := [][]int{
+		{0},
+		{1, 11},
+		{d, d + 1}, // Only parity lost, no inverse matrix needed.
+		{2, 0, 9, 13},
+		{0}, // Duplicated.
+	}
+	err = r.Warmup(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r.InverseCacheStats(); s.Len != 3 || s.Hits != 0 || s.Misses != 0 {
+		t.Fatalf("stats mismatched: %+v", s)
+	}
+
+	// Reconst with warmed up patterns hits the cache.
+	vects := make([][]byte, d+p)
+	for i := range vects {
+		vects[i] = make([]byte, testSize)
+	}
+	for i := 0; i < d; i++ {
+		fillRandom(vects[i])
+	}
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lost := range patterns {
+		err = r.Reconst(vects, nil, lost)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := r.InverseCacheStats(); s.Misses != 0 || s.Hits != 4 {
+		t.Fatalf("warmed up patterns should hit the cache: %+v", s)
+	}
+
+	if err = r.Warmup([][]int{{0, 1, 2, 3, 4}}); err != ErrTooManyLost {
+		t.Fatalf("exp: %v, got: %v", ErrTooManyLost, err)
+	}
+	if err = r.Warmup([][]int{{d + p}}); err != ErrIllegalVects {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVects, err)
+	}
+}
@@ -7,6 +7,8 @@ package reedsolomon
 
 import (
 	"errors"
+	"fmt"
+	"hash/crc32"
 )
 
 // matrix stores row*column bytes in a single flat slice.
@@ -27,14 +29,14 @@ type matrix []byte
 // incompatible results.
 //
 // The common approach is to start from a Vandermonde matrix and use
-// elementary transformations to make the upper part identity.
-// That approach is slightly more complex.
+// elementary transformations to make the upper part identity,
+// see makeVandermondeEncodeMatrix (WithMatrix(VandermondeMatrix)).
 //
 // A known incorrect pattern (documented in ISA-L and in this repository)
 // is to combine an upper identity matrix with a lower Vandermonde matrix directly;
 // that can produce singular sub-matrices.
 // See invertible.jpeg for a proof.
-func makeEncodeMatrix(d, p int) matrix {
+func makeEncodeMatrix(f *galoisField, d, p int) matrix {
 	r := d + p
 	m := make([]byte, r*d)
 	// Build upper identity matrix.
@@ -46,43 +48,282 @@ func makeEncodeMatrix(d, p int) matrix {
 	off := d * d // Skip the identity matrix.
 	for i := d; i < r; i++ {
 		for j := 0; j < d; j++ {
-			m[off] = inverseTbl[i^j]
+			m[off] = f.inv(byte(i ^ j))
 			off++
 		}
 	}
 	return m
 }
 
-func (m matrix) makeReconstMatrix(survived, needReconst []int) (rm matrix, err error) {
+// makeVandermondeEncodeMatrix builds an encoding matrix from a Vandermonde
+// matrix (row i is [1, i, i^2, ..., i^(d-1)]) by multiplying it with the inverse
+// of its upper d*d part, so the upper part becomes identity.
+// Any d rows of a Vandermonde matrix with distinct elements are linearly independent,
+// and multiplying by an invertible matrix keeps this property.
+//
+// It's the matrix used by klauspost/reedsolomon (default) and Backblaze's
+// JavaReedSolomon, so vectors encoded by them can be reconstructed by this library,
+// and vice versa.
+func makeVandermondeEncodeMatrix(f *galoisField, d, p int) matrix {
+	r := d + p
+	vm := make([]byte, r*d)
+	for i := 0; i < r; i++ {
+		var e byte = 1 // 0^0 = 1.
+		for j := 0; j < d; j++ {
+			vm[i*d+j] = e
+			e = f.mul(e, byte(i))
+		}
+	}
+	inv, err := matrix(vm[:d*d]).invert(f, d)
+	if err != nil {
+		panic(err) // Impossible: upper part is a Vandermonde matrix with distinct elements.
+	}
+
+	m := make([]byte, r*d)
+	for i := 0; i < d; i++ {
+		m[i*d+i] = 1
+	}
+	for i := d; i < r; i++ {
+		for j := 0; j < d; j++ {
+			var v byte
+			for k := 0; k < d; k++ {
+				v ^= f.mul(vm[i*d+k], inv[k*d+j])
+			}
+			m[i*d+j] = v
+		}
+	}
+	return m
+}
+
+// makeCustomEncodeMatrix builds an encoding matrix with identity matrix
+// upon generator matrix gen.
+func makeCustomEncodeMatrix(d, p int, gen []byte) matrix {
+	m := make([]byte, (d+p)*d)
+	for i := 0; i < d; i++ {
+		m[i*d+i] = 1
+	}
+	copy(m[d*d:], gen)
+	return m
+}
+
+// NotMDSError is returned by NewWithMatrix (with WithMDSCheck) when
+// the vectors in Survived can't reconstruct the others.
+type NotMDSError struct {
+	Survived []int // Survived are the rows of the first singular sub-matrix.
+}
+
+func (e *NotMDSError) Error() string {
+	return fmt.Sprintf("generator matrix is not MDS: sub-matrix of rows %v is singular", e.Survived)
+}
+
+// Unwrap returns ErrSingularMatrix.
+func (e *NotMDSError) Unwrap() error {
+	return ErrSingularMatrix
+}
+
+// checkMDS inverts every d*d sub-matrix of encoding matrix m, whose rows
+// are in lexicographic order, and returns *NotMDSError for the first
+// singular one. Sub-matrices without parity rows are identity matrices,
+// and they are skipped.
+func (m matrix) checkMDS(f *galoisField, d, p int) error {
+	n := d + p
+	rows := make([]int, d)
+	for i := range rows {
+		rows[i] = i
+	}
+	for {
+		// Next combination in lexicographic order.
+		i := d - 1
+		for i >= 0 && rows[i] == n-d+i {
+			i--
+		}
+		if i < 0 {
+			return nil
+		}
+		rows[i]++
+		for j := i + 1; j < d; j++ {
+			rows[j] = rows[j-1] + 1
+		}
+
+		_, err := m.makeEncMatrixForReconst(f, rows)
+		if err != nil {
+			return &NotMDSError{Survived: append([]int(nil), rows...)}
+		}
+	}
+}
+
+// makeEncodeMatrixOf builds an encoding matrix of type t.
+func makeEncodeMatrixOf(f *galoisField, t MatrixType, d, p int) matrix {
+	if t == VandermondeMatrix {
+		return makeVandermondeEncodeMatrix(f, d, p)
+	}
+	return makeEncodeMatrix(f, d, p)
+}
+
+// checksum returns the CRC32C of m.
+func (m matrix) checksum() uint32 {
+	return crc32.Checksum(m, crc32cTbl)
+}
+
+// makeReconstMatrix picks rows of needReconst data vectors from m,
+// which is the output of makeEncMatrixForReconst. rm is reused if it's big enough.
+func (m matrix) makeReconstMatrix(rm matrix, survived, needReconst []int) matrix {
 
 	d, nn := len(survived), len(needReconst)
-	rm = make([]byte, nn*d)
+	if cap(rm) < nn*d {
+		rm = make([]byte, nn*d)
+	}
+	rm = rm[:nn*d]
 	for i, l := range needReconst {
 		copy(rm[i*d:i*d+d], m[l*d:l*d+d])
 	}
-	return
+	return rm
+}
+
+// makeReconstMatrixFrom builds a matrix which computes needReconst vectors
+// (both data and parity are allowed) from survived vectors directly.
+// m is the encoding matrix and em is the inverse of its survived part
+// (see makeEncMatrixForReconst), so row i of rm is m[needReconst[i]] * em.
+// rm is reused if it's big enough.
+func (m matrix) makeReconstMatrixFrom(f *galoisField, rm, em matrix, d int, needReconst []int) matrix {
+
+	n := len(needReconst) * d
+	if cap(rm) < n {
+		rm = make([]byte, n)
+	}
+	rm = rm[:n]
+	for i, l := range needReconst {
+		row := rm[i*d : i*d+d]
+		if l < d { // Upper part of m is identity.
+			copy(row, em[l*d:l*d+d])
+			continue
+		}
+		for j := range row {
+			row[j] = 0
+		}
+		for k := 0; k < d; k++ {
+			c := m[l*d+k]
+			if c == 0 {
+				continue
+			}
+			for j := 0; j < d; j++ {
+				row[j] ^= f.mul(c, em[k*d+j])
+			}
+		}
+	}
+	return rm
 }
 
 // makeEncMatrixForReconst computes an encoding matrix for reconstruction by
 // inverting the survived portion of the original encoding matrix.
-func (m matrix) makeEncMatrixForReconst(survived []int) (em matrix, err error) {
+func (m matrix) makeEncMatrixForReconst(f *galoisField, survived []int) (em matrix, err error) {
 	d := len(survived)
 	m2 := make([]byte, d*d)
 	for i, l := range survived {
 		copy(m2[i*d:i*d+d], m[l*d:l*d+d])
 	}
-	em, err = matrix(m2).invert(len(survived))
+	em, err = matrix(m2).invert(f, len(survived))
 	if err != nil {
 		return
 	}
 	return
 }
 
+// makeCauchyEncMatrixForReconst is makeEncMatrixForReconst for the encoding
+// matrix made by makeEncodeMatrix. It uses the closed-form inverse of Cauchy
+// matrix, which costs O(d*k) multiplications (k is the number of lost data vectors)
+// instead of O(d^3) of Gauss-Jordan elimination.
+//
+// Let K be survived data rows, L be lost data rows, P be survived parity rows
+// (|L| = |P| = k), and s be survived vectors. Cauchy part of m is 1/(x+y)
+// (x is the row index, y is the column index), so data in K are s_K, and
+// data in L are B^-1 * (s_P + C[P][K] * s_K), where B is the k*k Cauchy matrix C[P][L].
+// By partial fraction decomposition of sum(z_l / (x+y_l)):
+//
+//	B^-1[l][b] = β_l * γ_b / (x_b+y_l)
+//	(B^-1 * C[P][K])[l][j] = α_j * β_l / (y_j+y_l)
+//	α_j = ∏_{l∈L}(y_j+y_l) / ∏_{b∈P}(y_j+x_b)
+//	β_l = ∏_{b∈P}(y_l+x_b) / ∏_{l'∈L,l'≠l}(y_l+y_l')
+//	γ_b = ∏_{l∈L}(x_b+y_l) / ∏_{b'∈P,b'≠b}(x_b+x_b')
+//
+// (Subtraction is addition in GF(2^8).)
+// It falls back to makeEncMatrixForReconst if survived isn't d distinct legal rows.
+func (m matrix) makeCauchyEncMatrixForReconst(f *galoisField, d int, survived []int) (em matrix, err error) {
+	r := len(m) / d
+	if len(survived) != d {
+		return m.makeEncMatrixForReconst(f, survived)
+	}
+	pos := make([]int, r) // Row -> column in em, -1 if it's not survived.
+	for i := range pos {
+		pos[i] = -1
+	}
+	for t, v := range survived {
+		if v < 0 || v >= r || pos[v] >= 0 {
+			return m.makeEncMatrixForReconst(f, survived)
+		}
+		pos[v] = t
+	}
+
+	em = make([]byte, d*d)
+	lost := make([]int, 0, d) // Lost data rows (L).
+	par := make([]int, 0, d)  // Survived parity rows (P).
+	for j := 0; j < d; j++ {
+		if pos[j] >= 0 {
+			em[j*d+pos[j]] = 1
+		} else {
+			lost = append(lost, j)
+		}
+	}
+	for _, v := range survived {
+		if v >= d {
+			par = append(par, v)
+		}
+	}
+	if len(lost) == 0 {
+		return
+	}
+
+	// prod returns ∏(a+v) for v in vs except a itself.
+	prod := func(a int, vs []int) byte {
+		var c byte = 1
+		for _, v := range vs {
+			if v != a {
+				c = f.mul(c, byte(a^v))
+			}
+		}
+		return c
+	}
+
+	alpha := make([]byte, d) // Only items of survived data rows are used.
+	for j := 0; j < d; j++ {
+		if pos[j] >= 0 {
+			alpha[j] = f.mul(prod(j, lost), f.inv(prod(j, par)))
+		}
+	}
+	gamma := make([]byte, len(par))
+	for b, x := range par {
+		gamma[b] = f.mul(prod(x, lost), f.inv(prod(x, par)))
+	}
+	for _, l := range lost {
+		beta := f.mul(prod(l, par), f.inv(prod(l, lost)))
+		row := em[l*d : l*d+d]
+		for b, x := range par {
+			row[pos[x]] = f.mul(f.mul(beta, gamma[b]), f.inv(byte(x^l)))
+		}
+		for j := 0; j < d; j++ {
+			if pos[j] >= 0 {
+				row[pos[j]] = f.mul(f.mul(alpha[j], beta), f.inv(byte(j^l)))
+			}
+		}
+	}
+	return
+}
+
 var ErrNotSquare = errors.New("not a square matrix")
 var ErrSingularMatrix = errors.New("matrix is singular")
 
 // invert computes and returns m's inverse matrix.
-func (m matrix) invert(n int) (inv matrix, err error) {
+func (m matrix) invert(f *galoisField, n int) (inv matrix, err error) {
 	if n*n != len(m) {
 		err = ErrNotSquare
 		return
@@ -118,11 +359,11 @@ func (m matrix) invert(n int) (inv matrix, err error) {
 		}
 
 		if left[i*n+i] != 1 {
-			v := inverseTbl[left[i*n+i]] // 1/pivot
+			v := f.inv(left[i*n+i]) // 1/pivot
 			// Scale the row so the pivot becomes 1.
 			for j := 0; j < n; j++ {
-				left[i*n+j] = gfMul(left[i*n+j], v)
-				inv[i*n+j] = gfMul(inv[i*n+j], v)
+				left[i*n+j] = f.mul(left[i*n+j], v)
+				inv[i*n+j] = f.mul(inv[i*n+j], v)
 			}
 		}
 
@@ -136,8 +377,8 @@ func (m matrix) invert(n int) (inv matrix, err error) {
 			v := left[j*n+i]
 			if v != 0 {
 				for k := 0; k < n; k++ {
-					left[j*n+k] ^= gfMul(v, left[i*n+k])
-					inv[j*n+k] ^= gfMul(v, inv[i*n+k])
+					left[j*n+k] ^= f.mul(v, left[i*n+k])
+					inv[j*n+k] ^= f.mul(v, inv[i*n+k])
 				}
 			}
 		}
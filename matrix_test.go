@@ -10,11 +10,12 @@ import (
 	"flag"
 	"fmt"
 	"math/bits"
+	"math/rand"
 	"testing"
 )
 
 func TestMakeEncodeMatrix(t *testing.T) {
-	act := makeEncodeMatrix(4, 4)
+	act := makeEncodeMatrix(defaultGF, 4, 4)
 	exp := []byte{
 		1, 0, 0, 0,
 		0, 1, 0, 0,
@@ -29,6 +30,21 @@ func TestMakeEncodeMatrix(t *testing.T) {
 	}
 }
 
+func TestMakeVandermondeEncodeMatrix(t *testing.T) {
+	// Same as klauspost/reedsolomon's buildMatrix(3, 5).
+	exp := matrix{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+		1, 1, 1,
+		15, 8, 6,
+	}
+	act := makeVandermondeEncodeMatrix(defaultGF, 3, 2)
+	if !bytes.Equal(exp, act) {
+		t.Fatalf("mismatched, exp: %v, got: %v", exp, act)
+	}
+}
+
 func TestMatrixSwap(t *testing.T) {
 	n := 7
 	m := make([]byte, n*n)
@@ -113,7 +129,7 @@ func TestMatrixInvert(t *testing.T) {
 
 	for i, c := range testCases {
 		m := matrix(c.matrixData)
-		actual, actualErr := m.invert(c.n)
+		actual, actualErr := m.invert(defaultGF, c.n)
 		if actualErr != nil && c.ok {
 			t.Errorf("case.%d, expected to pass, but failed with: <ERROR> %s", i+1, actualErr.Error())
 		}
@@ -135,9 +151,9 @@ func TestMatrixInvert(t *testing.T) {
 
 func TestMakeEncMatrixForReconst(t *testing.T) {
 	d, p := 4, 4
-	em := makeEncodeMatrix(d, p)
+	em := makeEncodeMatrix(defaultGF, d, p)
 	survivied, _ := genIdxForTest(d, p, d, p)
-	emr, err := em.makeEncMatrixForReconst(survivied)
+	emr, err := em.makeEncMatrixForReconst(defaultGF, survivied)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -150,17 +166,66 @@ func TestMakeEncMatrixForReconst(t *testing.T) {
 	}
 }
 
+func TestMakeCauchyEncMatrixForReconst(t *testing.T) {
+	dps := [][2]int{
+		{1, 1},
+		{4, 4},
+		{10, 4},
+		{12, 20},
+		{64, 64},
+		{128, 128},
+		{255, 1},
+		{1, 255},
+	}
+	f, err := getGaloisField(0x12b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fd := range []*galoisField{defaultGF, f} {
+		for _, dp := range dps {
+			d, p := dp[0], dp[1]
+			m := makeEncodeMatrix(fd, d, p)
+			for i := 0; i < 8; i++ {
+				survived, _ := genIdxForTest(d, p, d, p)
+				if i%2 == 1 { // Order of survived decides columns of em.
+					rand.Shuffle(d, func(i, j int) {
+						survived[i], survived[j] = survived[j], survived[i]
+					})
+				}
+				exp, err := m.makeEncMatrixForReconst(fd, survived)
+				if err != nil {
+					t.Fatal(err)
+				}
+				act, err := m.makeCauchyEncMatrixForReconst(fd, d, survived)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(exp, act) {
+					t.Fatalf("(%d+%d) poly: %#x, mismatched inverse matrix, survived: %v", d, p, fd.poly, survived)
+				}
+			}
+		}
+	}
+
+	// Illegal survived falls back to Gauss-Jordan elimination.
+	m := makeEncodeMatrix(defaultGF, 4, 4)
+	if _, err = m.makeCauchyEncMatrixForReconst(defaultGF, 4, []int{0, 1, 5, 5}); err != ErrSingularMatrix {
+		t.Fatalf("exp: %v, got: %v", ErrSingularMatrix, err)
+	}
+}
+
 // Check all sub-matrices when one or more vectors are missing.
 // Warning:
 // Do not use very large numbers here.
 // The number of combinations can explode and make the test impractical.
 func TestEncMatrixInvertibleAll(t *testing.T) {
-	testEncMatrixInvertible(t, 10, 4)
-	testEncMatrixInvertible(t, 15, 4)
+	testEncMatrixInvertible(t, makeEncodeMatrix(defaultGF, 10, 4), 10, 4)
+	testEncMatrixInvertible(t, makeEncodeMatrix(defaultGF, 15, 4), 15, 4)
+	testEncMatrixInvertible(t, makeVandermondeEncodeMatrix(defaultGF, 10, 4), 10, 4)
+	testEncMatrixInvertible(t, makeVandermondeEncodeMatrix(defaultGF, 15, 4), 15, 4)
 }
 
-func testEncMatrixInvertible(t *testing.T, d, p int) {
-	encMatrix := makeEncodeMatrix(d, p)
+func testEncMatrixInvertible(t *testing.T, encMatrix matrix, d, p int) {
 	var bitmap uint64
 	cnt := 0
 	// More missing vectors means a larger bitmap range.
@@ -186,7 +251,7 @@ func testEncMatrixInvertible(t *testing.T, d, p int) {
 		for i := 0; i < d; i++ {
 			copy(m[i*d:i*d+d], encMatrix[dpHas[i]*d:dpHas[i]*d+d])
 		}
-		im, err := matrix(m).invert(d)
+		im, err := matrix(m).invert(defaultGF, d)
 		if err != nil {
 			t.Fatalf("encode matrix is singular, d:%d, p:%d, dpHas:%#v", d, p, dpHas)
 		}
@@ -220,14 +285,14 @@ func TestEncMatrixInvertibleRandom(t *testing.T) {
 				continue
 			}
 
-			encMatrix := makeEncodeMatrix(d, p)
+			encMatrix := makeEncodeMatrix(defaultGF, d, p)
 			survived, _ := genIdxForTest(d, p, d, p)
 			m := make([]byte, d*d)
 			for i := 0; i < d; i++ {
 				copy(m[i*d:i*d+d], encMatrix[survived[i]*d:survived[i]*d+d])
 			}
 
-			im, err := matrix(m).invert(d)
+			im, err := matrix(m).invert(defaultGF, d)
 			if err != nil {
 				t.Fatalf("encode matrix is singular, d:%d, p:%d, dpHas:%#v", d, p, survived)
 			}
@@ -248,7 +313,7 @@ func mul(a, b matrix, n int) (out matrix) {
 		for j := 0; j < n; j++ {
 			d := byte(0)
 			for k := 0; k < n; k++ {
-				d ^= gfMul(a[n*i+k], b[n*k+j])
+				d ^= defaultGF.mul(a[n*i+k], b[n*k+j])
 
 			}
 			out[i*n+j] = d
@@ -275,18 +340,38 @@ func BenchmarkMakeEncMatrixForReconst(b *testing.B) {
 		{255, 1},
 		{256, 0},
 	}
-	benchMatrixInvertRun(b, dps)
+	benchMatrixInvertRun(b, dps, false)
 }
 
-func benchMatrixInvertRun(b *testing.B, dps [][2]int) {
+// BenchmarkMakeCauchyEncMatrixForReconst is BenchmarkMakeEncMatrixForReconst
+// with the closed-form inverse of Cauchy matrix.
+func BenchmarkMakeCauchyEncMatrixForReconst(b *testing.B) {
+	dps := [][2]int{ // data, parity
+		{4, 4},
+		{10, 4},
+		{16, 16},
+		{64, 64},
+		{128, 128},
+		{255, 1},
+		{256, 0},
+	}
+	benchMatrixInvertRun(b, dps, true)
+}
+
+func benchMatrixInvertRun(b *testing.B, dps [][2]int, cauchy bool) {
 	for _, dp := range dps {
 		d, p := dp[0], dp[1]
 		b.Run(fmt.Sprintf("(%d+%d)", d, p), func(b *testing.B) {
-			m := makeEncodeMatrix(d, p)
+			m := makeEncodeMatrix(defaultGF, d, p)
 			survived, _ := genIdxForTest(d, p, d, p)
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
-				_, err := m.makeEncMatrixForReconst(survived)
+				var err error
+				if cauchy {
+					_, err = m.makeCauchyEncMatrixForReconst(defaultGF, d, survived)
+				} else {
+					_, err = m.makeEncMatrixForReconst(defaultGF, survived)
+				}
 				if err != nil {
 					b.Fatal(err)
 				}
@@ -0,0 +1,149 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+)
+
+// Option configures an RS instance created by New.
+type Option func(*options)
+
+type options struct {
+	inverseCacheBytes int
+	cpuFeat           int
+	matrixType        MatrixType
+	genMatrix         []byte // Generator matrix of CustomMatrix.
+	checkMDS          bool
+	poly              int
+	concurrency       int
+}
+
+func defaultOptions() *options {
+	return &options{
+		inverseCacheBytes: -1, // Uses the shared cache.
+		cpuFeat:           featUnknown,
+		matrixType:        CauchyMatrix,
+		poly:              DefaultPolynomial,
+		concurrency:       1,
+	}
+}
+
+var (
+	ErrUnsupportedFeature = errors.New("unsupported CPU feature")
+	ErrUnknownMatrix      = errors.New("unknown matrix type")
+)
+
+func (o *options) check() error {
+	switch o.cpuFeat {
+	case featUnknown, featNoSIMD:
+	case featAVX2:
+		if getCPUFeature() != featAVX2 {
+			return ErrUnsupportedFeature
+		}
+	default:
+		return ErrUnsupportedFeature
+	}
+
+	switch o.matrixType {
+	case CauchyMatrix, VandermondeMatrix:
+	case CustomMatrix:
+		if o.genMatrix == nil { // Only NewWithMatrix could make CustomMatrix.
+			return ErrUnknownMatrix
+		}
+	default:
+		return ErrUnknownMatrix
+	}
+	return nil
+}
+
+// WithInverseCacheBytes gives the RS its own cache of inverse matrices
+// (which are used for reconstruction) with max total size n.
+// When the cache is full, matrices of least recently used failure patterns
+// are evicted. n <= 0 disables the cache.
+// Default: the cache shared by all RS instances, see SetInverseCacheBudget.
+func WithInverseCacheBytes(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.inverseCacheBytes = n
+	}
+}
+
+// CPUFeature is the instruction set used by Galois-field multiplication.
+type CPUFeature int
+
+const (
+	// AVX2 uses AVX2 instructions, New returns ErrUnsupportedFeature if
+	// the CPU doesn't support it.
+	AVX2 CPUFeature = featAVX2
+	// NoSIMD uses pure Go code, mostly for testing and comparing.
+	NoSIMD CPUFeature = featNoSIMD
+)
+
+// WithCPUFeature overrides the CPU feature detected at runtime.
+func WithCPUFeature(f CPUFeature) Option {
+	return func(o *options) {
+		o.cpuFeat = int(f)
+	}
+}
+
+// MatrixType identifies the construction of the encoding matrix.
+type MatrixType uint8
+
+const (
+	// CauchyMatrix is the default: identity matrix upon Cauchy matrix,
+	// see makeEncodeMatrix for details.
+	CauchyMatrix MatrixType = iota
+	// VandermondeMatrix is Vandermonde matrix reduced to systematic form,
+	// which is compatible with klauspost/reedsolomon and Backblaze's JavaReedSolomon,
+	// see makeVandermondeEncodeMatrix for details.
+	VandermondeMatrix
+	// CustomMatrix is identity matrix upon a caller-provided generator matrix,
+	// see NewWithMatrix.
+	CustomMatrix
+)
+
+// WithMatrix sets the construction of the encoding matrix.
+// Vectors encoded with one matrix type can't be reconstructed with another.
+func WithMatrix(t MatrixType) Option {
+	return func(o *options) {
+		o.matrixType = t
+	}
+}
+
+// WithMDSCheck makes NewWithMatrix verify that the generator matrix is MDS,
+// which means any DataNum vectors could reconstruct the others.
+// It inverts every DataNum*DataNum sub-matrix of the encoding matrix,
+// which may be very slow for wide stripes (see mathtool/cntinverse).
+// It has no effect on built-in matrices, which are MDS.
+func WithMDSCheck() Option {
+	return func(o *options) {
+		o.checkMDS = true
+	}
+}
+
+// WithPolynomial sets the polynomial of GF(2^8), which must be irreducible
+// and written with the x^8 bit (e.g. 0x11d). All 16 primitive polynomials of
+// degree 8 (enumerated by mathtool/gentbls) are supported, and so are other
+// irreducible ones such as 0x11b (AES).
+// Tables of polynomials other than DefaultPolynomial are generated when
+// they're used at the first time (about 72 KiB for each polynomial).
+// Vectors encoded with one polynomial can't be reconstructed with another.
+func WithPolynomial(poly int) Option {
+	return func(o *options) {
+		o.poly = poly
+	}
+}
+
+// WithConcurrency sets the max number of goroutines used by one call,
+// see SetConcurrency for details.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	d, p := testDataNum, testParityNum
+
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.inverseCache != sharedInverseCache || r.layout == 0 {
+		t.Fatal("default inverse cache mismatched")
+	}
+	if r.cpuFeat != getCPUFeature() || r.concurrency != 1 || r.matrixType != CauchyMatrix {
+		t.Fatal("default options mismatched")
+	}
+
+	r, err = New(d, p, WithInverseCacheBytes(0), WithCPUFeature(NoSIMD),
+		WithMatrix(CauchyMatrix), WithConcurrency(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.inverseCache != nil {
+		t.Fatal("inverse cache should be disabled")
+	}
+	if r.cpuFeat != featNoSIMD {
+		t.Fatal("cpu feature mismatched")
+	}
+	if r.concurrency != 4 {
+		t.Fatal("concurrency mismatched")
+	}
+
+	r, err = New(d, p, WithMatrix(VandermondeMatrix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.matrixType != VandermondeMatrix || !bytes.Equal(r.encMatrix, makeVandermondeEncodeMatrix(defaultGF, d, p)) {
+		t.Fatal("matrix mismatched")
+	}
+
+	r, err = New(d, p, WithInverseCacheBytes(d*d*3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.inverseCache == sharedInverseCache || r.inverseCache.maxBytes != d*d*3 {
+		t.Fatalf("inverse cache max mismatched, exp: %d, got: %d", d*d*3, r.inverseCache.maxBytes)
+	}
+}
+
+func TestNewWithIllegalOptions(t *testing.T) {
+	d, p := testDataNum, testParityNum
+
+	if _, err := New(d, p, WithMatrix(MatrixType(255))); err != ErrUnknownMatrix {
+		t.Fatalf("exp: %v, got: %v", ErrUnknownMatrix, err)
+	}
+	if _, err := New(d, p, WithCPUFeature(CPUFeature(255))); err != ErrUnsupportedFeature {
+		t.Fatalf("exp: %v, got: %v", ErrUnsupportedFeature, err)
+	}
+	_, err := New(d, p, WithCPUFeature(AVX2))
+	if getCPUFeature() == featAVX2 {
+		if err != nil {
+			t.Fatal(err)
+		}
+	} else if err != ErrUnsupportedFeature {
+		t.Fatalf("exp: %v, got: %v", ErrUnsupportedFeature, err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package reedsolomon
+
+// raceEnabled reports whether the race detector is enabled.
+// sync.Pool drops items randomly with race detector, so allocation tests are skipped.
+const raceEnabled = true
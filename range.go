@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+)
+
+var ErrIllegalRange = errors.New("illegal offset/length: out of vector range")
+
+// EncodeRange is like Encode, but only encodes bytes in [off, off+n)
+// of each vector, other bytes are untouched.
+func (r *RS) EncodeRange(vects [][]byte, off, n int) (err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	err = checkRange(len(vects[0]), off, n)
+	if err != nil {
+		return
+	}
+	r.encodeRange(r.GenMatrix, vects[:r.DataNum], vects[r.DataNum:], off, off+n, false)
+	return
+}
+
+// VerifyRange is like Verify, but only checks bytes in [off, off+n)
+// of each vector.
+func (r *RS) VerifyRange(vects [][]byte, off, n int) (ok bool, err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	err = checkRange(len(vects[0]), off, n)
+	if err != nil {
+		return
+	}
+	ms := r.verify(vects, off, off+n, true)
+	return len(ms) == 0, nil
+}
+
+// ReconstRange is like Reconst, but only reconstructs bytes in [off, off+n)
+// of needReconst vectors, and only reads the same range of survived vectors.
+// It's useful for degraded reads, which cost proportional to the bytes requested.
+func (r *RS) ReconstRange(vects [][]byte, survived, needReconst []int, off, n int) (err error) {
+
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	var dataNeedReconstN, size int
+	survived, needReconst, dataNeedReconstN, err = r.checkReconst(ws, survived, needReconst)
+	if err != nil {
+		if errors.Is(err, ErrNoNeedReconst) {
+			return nil
+		}
+		return
+	}
+	size, err = r.checkReconstVects(vects, survived, needReconst)
+	if err != nil {
+		return
+	}
+	err = checkRange(size, off, n)
+	if err != nil {
+		return
+	}
+	return r.reconstRange(ws, vects, survived, needReconst, dataNeedReconstN, off, off+n)
+}
+
+func checkRange(size, off, n int) error {
+	if off < 0 || n <= 0 || off+n > size {
+		return ErrIllegalRange
+	}
+	return nil
+}
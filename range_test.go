@@ -0,0 +1,210 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// randRange returns a random non-empty range in [0, size).
+func randRange(size int) (off, n int) {
+	off = rand.Intn(size)
+	n = rand.Intn(size-off) + 1
+	return
+}
+
+func TestRS_EncodeRange(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 15, 16, 17, testSize, 64*kib + 20} {
+		for i := 0; i < 32; i++ {
+			exp := make([][]byte, d+p)
+			act := make([][]byte, d+p)
+			for j := range exp {
+				exp[j], act[j] = make([]byte, size), make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(exp[j])
+				copy(act[j], exp[j])
+			}
+			for j := d; j < d+p; j++ {
+				fillRandom(act[j])
+			}
+			old := make([][]byte, p)
+			for j := range old {
+				old[j] = make([]byte, size)
+				copy(old[j], act[d+j])
+			}
+
+			err = r.Encode(exp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			off, n := randRange(size)
+			err = r.EncodeRange(act, off, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for j := d; j < d+p; j++ {
+				if !bytes.Equal(exp[j][off:off+n], act[j][off:off+n]) {
+					t.Fatalf("mismatched in range, vect: %d, size: %d, off: %d, n: %d", j, size, off, n)
+				}
+				if !bytes.Equal(old[j-d][:off], act[j][:off]) || !bytes.Equal(old[j-d][off+n:], act[j][off+n:]) {
+					t.Fatalf("modified out of range, vect: %d, size: %d, off: %d, n: %d", j, size, off, n)
+				}
+			}
+		}
+	}
+}
+
+func TestRS_VerifyRange(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p, size := testDataNum, testParityNum, testSize
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 128; i++ {
+		vects := make([][]byte, d+p)
+		for j := range vects {
+			vects[j] = make([]byte, size)
+		}
+		for j := 0; j < d; j++ {
+			fillRandom(vects[j])
+		}
+		err = r.Encode(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		off, n := randRange(size)
+		bad := rand.Intn(size)
+		vects[d+rand.Intn(p)][bad] ^= 1
+
+		ok, err := r.VerifyRange(vects, off, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := bad < off || bad >= off+n; ok != exp {
+			t.Fatalf("verify range mismatched, exp: %t, got: %t, corrupted: %d, off: %d, n: %d",
+				exp, ok, bad, off, n)
+		}
+	}
+}
+
+func TestRS_ReconstRange(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 17, testSize, 64*kib + 20} {
+		for i := 0; i < 32; i++ {
+			exp := make([][]byte, d+p)
+			act := make([][]byte, d+p)
+			for j := range exp {
+				exp[j], act[j] = make([]byte, size), make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(exp[j])
+			}
+			err = r.Encode(exp)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			survived, needReconst := genIdxForTest(d, p, rand.Intn(d+p), rand.Intn(p)+1)
+			for _, j := range survived {
+				copy(act[j], exp[j])
+			}
+
+			off, n := randRange(size)
+			err = r.ReconstRange(act, survived, needReconst, off, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			zero := make([]byte, size)
+			for _, j := range needReconst {
+				if !bytes.Equal(exp[j][off:off+n], act[j][off:off+n]) {
+					t.Fatalf("mismatched vect: %d, size: %d, off: %d, n: %d", j, size, off, n)
+				}
+				if !bytes.Equal(zero[:off], act[j][:off]) || !bytes.Equal(zero[off+n:], act[j][off+n:]) {
+					t.Fatalf("modified out of range, vect: %d, size: %d, off: %d, n: %d", j, size, off, n)
+				}
+			}
+		}
+	}
+}
+
+func TestCheckRange(t *testing.T) {
+	cases := []struct {
+		size, off, n int
+		err          error
+	}{
+		{16, 0, 16, nil},
+		{16, 15, 1, nil},
+		{16, 0, 0, ErrIllegalRange},
+		{16, -1, 1, ErrIllegalRange},
+		{16, 8, 9, ErrIllegalRange},
+		{16, 16, 1, ErrIllegalRange},
+	}
+	for i, c := range cases {
+		if err := checkRange(c.size, c.off, c.n); err != c.err {
+			t.Fatalf("case: %d, exp: %v, got: %v", i, c.err, err)
+		}
+	}
+}
+
+func BenchmarkRS_ReconstRange(b *testing.B) {
+	d, p := 10, 4
+	size, n := mib, 64*kib
+
+	survived, needReconst := genIdxForTest(d, p, d, 1)
+	b.Run(fmt.Sprintf("(%d+%d)-%s-range_%s-%s", d, p, byteToStr(size), byteToStr(n), featToStr(getCPUFeature())),
+		func(b *testing.B) {
+			vects := make([][]byte, d+p)
+			for j := range vects {
+				vects[j] = make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(vects[j])
+			}
+			r, err := New(d, p)
+			if err != nil {
+				b.Fatal(err)
+			}
+			err = r.Encode(vects)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64((d + len(needReconst)) * n))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err = r.ReconstRange(vects, survived, needReconst, size/2, n)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+}
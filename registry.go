@@ -0,0 +1,70 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"sync"
+)
+
+// codecLayout identifies the encoding matrix of RS instances,
+// CustomMatrix isn't registered (see newWithOptions).
+type codecLayout struct {
+	dataNum    int
+	parityNum  int
+	matrixType MatrixType
+	poly       int
+}
+
+// sharedCodec is shared by all RS instances with the same layout.
+type sharedCodec struct {
+	id        uint32 // Layout ID in the inverse cache, starts from 1.
+	encMatrix matrix
+}
+
+var (
+	codecsMu sync.Mutex
+	codecs   = make(map[codecLayout]*sharedCodec) // Layouts made by now.
+
+	// sharedInverseCache is used by all RS instances without WithInverseCacheBytes.
+	sharedInverseCache = newInverseCache(maxInverseMatrixCapInCache)
+)
+
+// getSharedCodec returns the shared codec of layout l,
+// makeMatrix is called to make the encoding matrix at the first time.
+func getSharedCodec(l codecLayout, makeMatrix func() matrix) *sharedCodec {
+	codecsMu.Lock()
+	c, ok := codecs[l]
+	codecsMu.Unlock()
+	if ok {
+		return c
+	}
+
+	e := makeMatrix() // Don't block other layouts.
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	c, ok = codecs[l]
+	if ok { // Made by another goroutine.
+		return c
+	}
+	c = &sharedCodec{id: uint32(len(codecs) + 1), encMatrix: e}
+	codecs[l] = c
+	return c
+}
+
+// SetInverseCacheBudget sets the max total size of inverse matrices in
+// the cache shared by all RS instances, matrices are evicted if the cache
+// is bigger than n. n <= 0 disables caching in the shared cache.
+// Default: 16 MiB.
+//
+// RS instances with the same DataNum, ParityNum, matrix and polynomial
+// share cached inverse matrices, so it's cheap to make an RS per request.
+// WithInverseCacheBytes gives an RS its own cache instead, and RS made by
+// NewWithMatrix always has its own cache.
+func SetInverseCacheBudget(n int) {
+	sharedInverseCache.setMaxBytes(n)
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRS_SharedCodec(t *testing.T) {
+	d, p := 17, 3 // Not used by other tests.
+
+	r1, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := New(d, p, WithCPUFeature(NoSIMD), WithConcurrency(2)) // Don't affect layout.
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &r1.encMatrix[0] != &r2.encMatrix[0] || r1.layout != r2.layout ||
+		r1.inverseCache != sharedInverseCache || r2.inverseCache != sharedInverseCache {
+		t.Fatal("RS with the same layout should share codec")
+	}
+
+	survived, _ := genIdxForTest(d, p, d, p)
+	survived[d-1] = d + p - 1
+	exp, err := r1.getEncMatrixForReconst(survived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s0 := r1.InverseCacheStats()
+	act, err := r2.getEncMatrixForReconst(survived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exp, act) {
+		t.Fatal("shared matrix mismatched")
+	}
+	if s := r2.InverseCacheStats(); s.Hits <= s0.Hits {
+		t.Fatalf("inverse matrix should be shared: %+v -> %+v", s0, s)
+	}
+
+	gen := make([]byte, p*d)
+	copy(gen, r1.GenMatrix)
+	others := make([]*RS, 0, 4)
+	for _, opts := range [][]Option{
+		{WithMatrix(VandermondeMatrix)},
+		{WithPolynomial(0x12b)},
+		{WithInverseCacheBytes(mib)},
+	} {
+		r, err := New(d, p, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		others = append(others, r)
+	}
+	r, err := New(d, p+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	others = append(others, r)
+	for i, r := range others {
+		if r.inverseCache == sharedInverseCache && r.layout == r1.layout {
+			t.Fatalf("case %d: different layouts share inverse matrices", i)
+		}
+	}
+
+	// Custom matrices have their own caches.
+	c1, err := NewWithMatrix(d, p, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := NewWithMatrix(d, p, gen, WithMDSCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.inverseCache == nil || c1.inverseCache == sharedInverseCache || c1.inverseCache == c2.inverseCache {
+		t.Fatal("custom matrix should have its own inverse cache")
+	}
+}
+
+func TestRS_CustomMatrixNotRegistered(t *testing.T) {
+	d, p := 6, 3
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codecsMu.Lock()
+	n := len(codecs)
+	codecsMu.Unlock()
+
+	gen := make([]byte, p*d)
+	copy(gen, r.GenMatrix)
+	for i := 0; i < 100; i++ {
+		gen[0] = byte(i + 1)
+		_, err = NewWithMatrix(d, p, gen)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if len(codecs) != n {
+		t.Fatalf("custom matrices shouldn't be registered, layouts: %d -> %d", n, len(codecs))
+	}
+}
+
+func TestSetInverseCacheBudget(t *testing.T) {
+	defer SetInverseCacheBudget(maxInverseMatrixCapInCache)
+
+	d, p := 19, 5 // Not used by other tests.
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 8; i++ {
+		survived, _ := genIdxForTest(d, p, d, p)
+		if _, err = r.getEncMatrixForReconst(survived); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetInverseCacheBudget(d * d * 2)
+	if s := r.InverseCacheStats(); s.Bytes > d*d*2 || s.MaxBytes != d*d*2 {
+		t.Fatalf("cache should be shrunk: %+v", s)
+	}
+
+	SetInverseCacheBudget(0)
+	survived, _ := genIdxForTest(d, p, d, p)
+	if _, err = r.getEncMatrixForReconst(survived); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.InverseCacheStats(); s.Len != 0 || s.Bytes != 0 {
+		t.Fatalf("cache should be empty: %+v", s)
+	}
+}
@@ -4,8 +4,9 @@
 // that can be found in the LICENSE file.
 
 // Package reedsolomon implements systematic erasure coding based on
-// Reed-Solomon codes over GF(2^8), using the primitive polynomial
-// x^8+x^4+x^3+x^2+1.
+// Reed-Solomon codes over GF(2^8) (RS), using the primitive polynomial
+// x^8+x^4+x^3+x^2+1 by default (see WithPolynomial),
+// and over GF(2^16) (RS16) for stripes which have more than 256 vectors.
 //
 // Galois field arithmetic is accelerated with SIMD instructions (AVX2).
 package reedsolomon
@@ -13,7 +14,6 @@ package reedsolomon
 import (
 	"errors"
 	"sync"
-	"sync/atomic"
 
 	"github.com/templexxx/cpu"
 	xor "github.com/templexxx/xorsimd"
@@ -27,16 +27,23 @@ type RS struct {
 	// CPU feature flags. SIMD significantly improves performance.
 	cpuFeat int
 
-	encMatrix matrix // Encoding matrix.
-	GenMatrix matrix // Generator matrix.
+	// Encoding & generator matrices are shared by RS instances with the same layout.
+	encMatrix  matrix     // Encoding matrix.
+	GenMatrix  matrix     // Generator matrix, it must not be modified.
+	matrixType MatrixType // Construction of encMatrix.
 
-	inverseCacheEnabled bool
-	inverseCache        *sync.Map // Cache of inverse matrices.
+	// Cache of inverse matrices, nil if it's disabled.
 	// Cache limit: total possible inverse matrices = C(DataNum+ParityNum, DataNum)
 	// = (DataNum+ParityNum)! / ParityNum!DataNum!
 	// Without a cap, memory usage can grow too large. See mathtool/cntinverse for details.
-	inverseCacheMax uint64
-	inverseCacheN   uint64 // Number of cached inverse matrices.
+	inverseCache *inverseCache
+	layout       uint32 // Layout ID in inverseCache, see sharedCodec.
+
+	// Max number of goroutines used by one Encode/Reconst/Update/Replace call.
+	// See SetConcurrency for details.
+	concurrency int
+
+	wsPool sync.Pool // Pool of *workspace.
 
 	*gmu
 }
@@ -47,43 +54,141 @@ const (
 	maxVects                   = 256
 	kib                        = 1024
 	mib                        = 1024 * kib
-	maxInverseMatrixCapInCache = 16 * mib // Keeping inverse matrix cache small, 16 MiB is enough for most cases.
+	maxInverseMatrixCapInCache = 16 * mib // Default budget of the shared inverse cache, 16 MiB is enough for most cases.
 )
 
 // New creates an RS instance with the given data and parity shard counts.
-func New(dataNum, parityNum int) (r *RS, err error) {
+// Options are applied in order, see Option for details.
+func New(dataNum, parityNum int, opts ...Option) (r *RS, err error) {
 
-	return newWithFeature(dataNum, parityNum, featUnknown)
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	return newWithOptions(dataNum, parityNum, o)
+}
+
+var ErrIllegalGenMatrix = errors.New("illegal generator matrix size: must be parityNum*dataNum")
+
+// NewWithMatrix creates an RS instance with a caller-provided generator matrix
+// (parityNum rows, dataNum columns, row-major), the encoding matrix is identity
+// matrix upon it. gen is copied.
+// The RS has its own inverse cache, which isn't shared with other instances.
+//
+// With WithMDSCheck, it returns a *NotMDSError if any DataNum vectors
+// can't reconstruct the others.
+func NewWithMatrix(dataNum, parityNum int, gen []byte, opts ...Option) (r *RS, err error) {
+	if dataNum <= 0 || parityNum <= 0 || dataNum+parityNum > maxVects {
+		return nil, ErrIllegalVects
+	}
+	if len(gen) != dataNum*parityNum {
+		return nil, ErrIllegalGenMatrix
+	}
+
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.matrixType = CustomMatrix
+	o.genMatrix = gen
+	return newWithOptions(dataNum, parityNum, o)
 }
 
 func newWithFeature(dataNum, parityNum, feat int) (r *RS, err error) {
+	o := defaultOptions()
+	o.cpuFeat = feat
+	return newWithOptions(dataNum, parityNum, o)
+}
+
+func newWithOptions(dataNum, parityNum int, o *options) (r *RS, err error) {
 	d, p := dataNum, parityNum
 	if d <= 0 || p <= 0 || d+p > maxVects {
 		return nil, ErrIllegalVects
 	}
+	err = o.check()
+	if err != nil {
+		return nil, err
+	}
+	f, err := getGaloisField(o.poly)
+	if err != nil {
+		return nil, err
+	}
 
-	e := makeEncodeMatrix(d, p)
+	var e matrix
+	var c *sharedCodec
+	if o.matrixType == CustomMatrix { // Not shared, for keeping the registry bounded.
+		e = makeCustomEncodeMatrix(d, p, o.genMatrix)
+		if o.checkMDS {
+			err = e.checkMDS(f, d, p)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		l := codecLayout{dataNum: d, parityNum: p, matrixType: o.matrixType, poly: o.poly}
+		c = getSharedCodec(l, func() matrix {
+			return makeEncodeMatrixOf(f, o.matrixType, d, p)
+		})
+		e = c.encMatrix
+	}
 	g := e[d*d:]
 	r = &RS{DataNum: d, ParityNum: p,
-		encMatrix: e, GenMatrix: g}
-
-	if r.DataNum+r.ParityNum <= 64 { // The cache key is a 64-bit bitmap.
-		r.inverseCacheEnabled = true
-		r.inverseCache = new(sync.Map)
-		r.inverseCacheMax = maxInverseMatrixCapInCache / uint64(r.DataNum) / uint64(r.DataNum)
+		encMatrix: e, GenMatrix: g, matrixType: o.matrixType}
+
+	if o.inverseCacheBytes < 0 { // Default.
+		if c != nil {
+			r.inverseCache = sharedInverseCache
+			r.layout = c.id
+		} else {
+			r.inverseCache = newInverseCache(maxInverseMatrixCapInCache)
+		}
+	} else if o.inverseCacheBytes >= d*d {
+		r.inverseCache = newInverseCache(o.inverseCacheBytes)
 	}
 
-	r.cpuFeat = feat
+	r.cpuFeat = o.cpuFeat
 	if r.cpuFeat == featUnknown {
 		r.cpuFeat = getCPUFeature()
 	}
 
-	r.gmu = new(gmu)
+	r.gmu = &gmu{gf: f}
 	r.initFunc(r.cpuFeat)
 
+	r.SetConcurrency(o.concurrency)
+
 	return
 }
 
+// SetConcurrency sets the max number of goroutines used by one
+// Encode/Reconst/Update/Replace call (and their range variants).
+// Vectors are split into contiguous parts for goroutines,
+// and small vectors are still processed in the calling goroutine
+// (see minParallelSize). n <= 1 disables parallelism, and it's the default.
+//
+// It must be called before r is used.
+func (r *RS) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	r.concurrency = n
+}
+
+// MatrixType returns the construction of r's encoding matrix.
+func (r *RS) MatrixType() MatrixType {
+	return r.matrixType
+}
+
+// MatrixChecksum returns the CRC32C of r's encoding matrix, which identifies
+// the matrix (e.g. different generator matrices of CustomMatrix).
+func (r *RS) MatrixChecksum() uint32 {
+	return r.encMatrix.checksum()
+}
+
+// Polynomial returns the polynomial of r's Galois field, see WithPolynomial.
+func (r *RS) Polynomial() int {
+	return r.gf.poly
+}
+
 // CPU features.
 const (
 	featUnknown = iota
@@ -139,17 +244,55 @@ func (r *RS) checkEncode(vects [][]byte) (err error) {
 // updateOnly means "XOR new results into existing output" instead of overwriting.
 // See Encode and Update for the difference.
 func (r *RS) encode(vects [][]byte, updateOnly bool) {
-	dv, pv := vects[:r.DataNum], vects[r.DataNum:]
-	size := len(vects[0])
-	splitSize := getSplitSize(size)
-	start := 0
-	for start < size {
-		end := start + splitSize
-		if end > size {
-			end = size
+	r.encodeRange(r.GenMatrix, vects[:r.DataNum], vects[r.DataNum:], 0, len(vects[0]), updateOnly)
+}
+
+// minParallelSize is the min number of bytes (in each vector) processed by
+// one goroutine. Below it, the cost of scheduling outweighs the gain.
+const minParallelSize = 128 * kib
+
+// encodeRange multiplies g (len(pv) rows * len(dv) columns) by bytes in
+// [start, end) of dv, and writes (or XORs when updateOnly) results into pv.
+// g may be any matrix (e.g. for reconstruction), not only r.GenMatrix.
+// It fans parts out to goroutines if it's allowed (see SetConcurrency).
+func (r *RS) encodeRange(g matrix, dv, pv [][]byte, start, end int, updateOnly bool) {
+	n := end - start
+	workers := r.concurrency
+	if workers > n/minParallelSize {
+		workers = n / minParallelSize
+	}
+	if workers <= 1 {
+		r.encodeSeq(g, dv, pv, start, end, updateOnly)
+		return
+	}
+
+	per := ((n/workers + 15) >> 4) << 4 // Keep parts 16 bytes aligned for SIMD.
+	var wg sync.WaitGroup
+	for s := start; s < end; s += per {
+		e := s + per
+		if e > end {
+			e = end
 		}
-		r.encodePart(start, end, dv, pv, updateOnly)
-		start = end
+		wg.Add(1)
+		go func(s, e int) {
+			defer wg.Done()
+			r.encodeSeq(g, dv, pv, s, e, updateOnly)
+		}(s, e)
+	}
+	wg.Wait()
+}
+
+// encodeSeq processes bytes in [start, end) of each vector chunk by chunk
+// in the calling goroutine.
+func (r *RS) encodeSeq(g matrix, dv, pv [][]byte, start, end int, updateOnly bool) {
+	splitSize := getSplitSize(end - start)
+	for start < end {
+		next := start + splitSize
+		if next > end {
+			next = end
+		}
+		r.encodePart(g, start, next, dv, pv, updateOnly)
+		start = next
 	}
 }
 
@@ -172,10 +315,10 @@ func getSplitSize(n int) int {
 	return l1d / 2
 }
 
-func (r *RS) encodePart(start, end int, dv, pv [][]byte, updateOnly bool) {
+func (r *RS) encodePart(g matrix, start, end int, dv, pv [][]byte, updateOnly bool) {
 	undone := end - start
 	do := (undone >> 4) << 4 // do could be 0(when undone < 16)
-	d, p, g := r.DataNum, r.ParityNum, r.GenMatrix
+	d, p := len(dv), len(pv)
 	if do >= 16 {
 		end2 := start + do
 		for i := 0; i < d; i++ {
@@ -190,12 +333,13 @@ func (r *RS) encodePart(start, end int, dv, pv [][]byte, updateOnly bool) {
 	}
 
 	if undone > do { // 0 < undone-do < 16
+		start2 := start + do
 		for i := 0; i < d; i++ {
 			for j := 0; j < p; j++ {
 				if i != 0 || updateOnly {
-					mulVectXORNoSIMD(g[j*d+i], dv[i][start:end], pv[j][start:end])
+					r.mulVectXORNoSIMD(g[j*d+i], dv[i][start2:end], pv[j][start2:end])
 				} else {
-					mulVectNoSIMD(g[j*d], dv[0][start:end], pv[j][start:end])
+					r.mulVectNoSIMD(g[j*d], dv[0][start2:end], pv[j][start2:end])
 				}
 			}
 		}
@@ -220,8 +364,55 @@ func (r *RS) encodePart(start, end int, dv, pv [][]byte, updateOnly bool) {
 // Reconstructed results are written directly into vects[needReconst].
 func (r *RS) Reconst(vects [][]byte, survived, needReconst []int) (err error) {
 
-	var dataNeedReconstN int
-	survived, needReconst, dataNeedReconstN, err = r.checkReconst(survived, needReconst)
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	var dataNeedReconstN, size int
+	survived, needReconst, dataNeedReconstN, err = r.checkReconst(ws, survived, needReconst)
+	if err != nil {
+		if errors.Is(err, ErrNoNeedReconst) {
+			return nil
+		}
+		return
+	}
+	size, err = r.checkReconstVects(vects, survived, needReconst)
+	if err != nil {
+		return
+	}
+	return r.reconstRange(ws, vects, survived, needReconst, dataNeedReconstN, 0, size)
+}
+
+// reconstRange reconstructs bytes in [start, end) of needReconst vectors.
+// Arguments must have been checked by checkReconst and checkReconstVects.
+func (r *RS) reconstRange(ws *workspace, vects [][]byte, survived, needReconst []int,
+	dataNeedReconstN, start, end int) (err error) {
+
+	err = r.reconstData(ws, vects, survived, needReconst[:dataNeedReconstN], start, end)
+	if err != nil {
+		return
+	}
+	r.reconstParity(ws, vects, needReconst[dataNeedReconstN:], start, end)
+	return
+}
+
+// ReconstInto is like Reconst, but survived vectors are read-only,
+// and reconstructed vectors are written into separately supplied buffers.
+// src contains all vectors, and len(src) must be dataNum + parityNum;
+// src[i] may be nil if vector i is not available.
+// dst maps indexes of vectors to reconstruct to their output buffers,
+// and all buffers must have the same size as survived vectors.
+// Keys in dst take precedence over survived as in Reconst.
+// If len(survived) == 0, all non-nil vectors in src are treated as survived.
+//
+// Both data and parity vectors are reconstructed from survived vectors directly,
+// so no buffer is needed for lost data vectors which aren't in dst.
+func (r *RS) ReconstInto(src [][]byte, survived []int, dst map[int][]byte) (err error) {
+
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	var needReconst []int
+	survived, needReconst, err = r.checkReconstInto(ws, src, survived, dst)
 	if err != nil {
 		if errors.Is(err, ErrNoNeedReconst) {
 			return nil
@@ -229,11 +420,91 @@ func (r *RS) Reconst(vects [][]byte, survived, needReconst []int) (err error) {
 		return
 	}
 
-	err = r.reconstData(vects, survived, needReconst[:dataNeedReconstN])
+	d := r.DataNum
+	survived = survived[:d] // Reconstruction only needs dataNum vectors.
+
+	size, err := checkVectsSize(src, survived)
+	if err != nil {
+		return
+	}
+	for _, buf := range dst {
+		if len(buf) != size {
+			return ErrMismatchVectSize
+		}
+	}
+
+	em, err := r.getEncMatrixForReconst(survived)
 	if err != nil {
 		return
 	}
-	return r.reconstParity(vects, needReconst[dataNeedReconstN:])
+	gm := r.encMatrix.makeReconstMatrixFrom(r.gf, ws.gm, em, d, needReconst)
+
+	nn := len(needReconst)
+	vs := ws.vs[:d+nn]
+	for i, row := range survived {
+		vs[i] = src[row]
+	}
+	for i, row := range needReconst {
+		vs[i+d] = dst[row]
+	}
+	r.reconst(vs, gm, 0, size)
+	return nil
+}
+
+// checkReconstInto validates arguments of ReconstInto and returns:
+// 1. survived indexes (sorted)
+// 2. indexes to reconstruct (sorted)
+func (r *RS) checkReconstInto(ws *workspace, src [][]byte, survived []int, dst map[int][]byte) (vs, nr []int, err error) {
+	if len(dst) == 0 {
+		err = ErrNoNeedReconst
+		return
+	}
+
+	d, p := r.DataNum, r.ParityNum
+	if len(src) != d+p {
+		err = ErrMismatchVects
+		return
+	}
+	if err = checkVectIdx(survived, d, p); err != nil {
+		return
+	}
+
+	status := ws.status
+	for i := range status {
+		status[i] = vectUnknown
+	}
+	if len(survived) == 0 {
+		for i, v := range src {
+			if v != nil {
+				status[i] = vectSurvived
+			}
+		}
+	}
+	for _, v := range survived {
+		status[v] = vectSurvived
+	}
+	for v := range dst {
+		if v < 0 || v >= d+p {
+			err = ErrIllegalVects
+			return
+		}
+		status[v] = vectNeedReconst
+	}
+
+	vs = ws.ints[:d+p][:0]
+	nr = ws.ints[d+p:][:0]
+	for i, s := range status {
+		switch s {
+		case vectSurvived:
+			vs = append(vs, i)
+		case vectNeedReconst:
+			nr = append(nr, i)
+		}
+	}
+	if len(vs) < d {
+		err = ErrTooManyLost
+	}
+	return
 }
 
 var (
@@ -261,7 +532,8 @@ func checkVectIdx(idx []int, d, p int) error {
 // 1. survived indexes
 // 2. data/parity indexes to reconstruct (sorted)
 // 3. number of data vectors to reconstruct
-func (r *RS) checkReconst(survived, needReconst []int) (vs, nr []int, dn int, err error) {
+// Results share memory with ws.
+func (r *RS) checkReconst(ws *workspace, survived, needReconst []int) (vs, nr []int, dn int, err error) {
 	if len(needReconst) == 0 {
 		err = ErrNoNeedReconst
 		return
@@ -276,12 +548,13 @@ func (r *RS) checkReconst(survived, needReconst []int) (vs, nr []int, dn int, er
 		return
 	}
 
-	status := make([]uint8, d+p)
-
+	status := ws.status
+	st := vectUnknown
 	if len(survived) == 0 { // Mark all vectors as survived if none are provided.
-		for i := range status {
-			status[i] = vectSurvived
-		}
+		st = vectSurvived
+	}
+	for i := range status {
+		status[i] = st
 	}
 	for _, v := range survived {
 		status[v] = vectSurvived
@@ -302,9 +575,8 @@ func (r *RS) checkReconst(survived, needReconst []int) (vs, nr []int, dn int, er
 		}
 	}
 
-	ints := make([]int, d+2*p)
-	vs = ints[:d+p][:0]
-	nr = ints[d+p:][:0]
+	vs = ws.ints[:d+p][:0]
+	nr = ws.ints[d+p:][:0]
 	for i, s := range status {
 		switch s {
 		case vectSurvived:
@@ -324,7 +596,40 @@ func (r *RS) checkReconst(survived, needReconst []int) (vs, nr []int, dn int, er
 	return
 }
 
-func (r *RS) reconstData(vects [][]byte, survived, needReconst []int) (err error) {
+// checkReconstVects checks vectors which are used in reconstruction
+// (see checkReconst for survived and needReconst), and returns their size.
+func (r *RS) checkReconstVects(vects [][]byte, survived, needReconst []int) (size int, err error) {
+	if len(vects) != r.DataNum+r.ParityNum {
+		return 0, ErrMismatchVects
+	}
+	// Only the first dataNum survived vectors are read.
+	// If any parity needs reconstruction, all data vectors are included
+	// in survived[:dataNum] and needReconst.
+	return checkVectsSize(vects, survived[:r.DataNum], needReconst)
+}
+
+// checkVectsSize checks that vects[idx] are non-empty and have the same size,
+// and returns the size.
+func checkVectsSize(vects [][]byte, idx ...[]int) (size int, err error) {
+	size = -1
+	for _, is := range idx {
+		for _, i := range is {
+			n := len(vects[i])
+			if size < 0 {
+				size = n
+			}
+			if n != size {
+				return 0, ErrMismatchVectSize
+			}
+		}
+	}
+	if size <= 0 {
+		return 0, ErrZeroVectSize
+	}
+	return
+}
+
+func (r *RS) reconstData(ws *workspace, vects [][]byte, survived, needReconst []int, start, end int) (err error) {
 
 	nn := len(needReconst)
 	if nn == 0 {
@@ -334,34 +639,35 @@ func (r *RS) reconstData(vects [][]byte, survived, needReconst []int) (err error
 	d := r.DataNum
 	survived = survived[:d] // Reconstruction only needs dataNum vectors.
 
-	gm, err := r.getReconstMatrix(survived, needReconst)
+	gm, err := r.getReconstMatrix(ws.gm, survived, needReconst)
 	if err != nil {
 		return
 	}
-	vs := make([][]byte, d+nn)
+	vs := ws.vs[:d+nn]
 	for i, row := range survived {
 		vs[i] = vects[row]
 	}
 	for i, row := range needReconst {
 		vs[i+d] = vects[row]
 	}
-	return r.reconst(vs, gm, nn)
+	r.reconst(vs, gm, start, end)
+	return nil
 }
 
-func (r *RS) reconstParity(vects [][]byte, needReconst []int) (err error) {
+func (r *RS) reconstParity(ws *workspace, vects [][]byte, needReconst []int, start, end int) {
 
 	nn := len(needReconst)
 	if nn == 0 {
-		return nil
+		return
 	}
 
 	d := r.DataNum
-	gm := make([]byte, nn*d)
+	gm := ws.gm[:nn*d]
 	for i, l := range needReconst {
 		copy(gm[i*d:i*d+d], r.encMatrix[l*d:l*d+d])
 	}
 
-	vs := make([][]byte, d+nn)
+	vs := ws.vs[:d+nn]
 	for i := 0; i < d; i++ {
 		vs[i] = vects[i]
 	}
@@ -369,54 +675,72 @@ func (r *RS) reconstParity(vects [][]byte, needReconst []int) (err error) {
 		vs[i+d] = vects[p]
 	}
 
-	return r.reconst(vs, gm, nn)
+	r.reconst(vs, gm, start, end)
 }
 
-func (r *RS) reconst(vects [][]byte, gm matrix, pn int) error {
+// reconst multiplies gm by vects[:dataNum] and writes results into vects[dataNum:],
+// only bytes in [start, end) are processed.
+func (r *RS) reconst(vects [][]byte, gm matrix, start, end int) {
 
-	rTmp := &RS{DataNum: r.DataNum, ParityNum: pn, GenMatrix: gm, cpuFeat: r.cpuFeat, gmu: r.gmu}
-	return rTmp.Encode(vects)
+	r.encodeRange(gm, vects[:r.DataNum], vects[r.DataNum:], start, end, false)
+}
 
+// getReconstMatrix returns the matrix for reconstructing needReconst data
+// vectors from survived vectors, rm is reused if it's big enough.
+func (r *RS) getReconstMatrix(rm matrix, survived, needReconst []int) (matrix, error) {
+
+	em, err := r.getEncMatrixForReconst(survived)
+	if err != nil {
+		return nil, err
+	}
+	return em.makeReconstMatrix(rm, survived, needReconst), nil
 }
 
-func (r *RS) getReconstMatrix(survived, needReconst []int) (rm []byte, err error) {
+// getEncMatrixForReconst returns the inverse of the survived part of
+// the encoding matrix, using the inverse matrix cache when it's enabled.
+func (r *RS) getEncMatrixForReconst(survived []int) (em matrix, err error) {
 
-	if !r.inverseCacheEnabled {
-		em, err2 := r.encMatrix.makeEncMatrixForReconst(survived)
-		if err2 != nil {
-			return nil, err2
-		}
-		return em.makeReconstMatrix(survived, needReconst)
+	if r.inverseCache == nil {
+		return r.makeEncMatrixForReconst(survived)
 	}
-	return r.getReconstMatrixFromCache(survived, needReconst)
+	return r.getEncMatrixForReconstFromCache(survived)
 }
 
-func (r *RS) getReconstMatrixFromCache(survived, needReconst []int) (rm matrix, err error) {
+func (r *RS) getEncMatrixForReconstFromCache(survived []int) (em matrix, err error) {
 
 	key := makeInverseCacheKey(survived)
 
-	emRaw, ok := r.inverseCache.Load(key)
+	em, ok := r.inverseCache.get(r.layout, key)
 	if ok {
-		em := emRaw.(matrix)
-		return em.makeReconstMatrix(survived, needReconst)
+		return em, nil
 	}
 
-	em, err := r.encMatrix.makeEncMatrixForReconst(survived)
+	em, err = r.makeEncMatrixForReconst(survived)
 	if err != nil {
 		return
 	}
-	if atomic.AddUint64(&r.inverseCacheN, 1) <= r.inverseCacheMax {
-		r.inverseCache.Store(key, em)
+	r.inverseCache.add(r.layout, key, em)
+	return
+}
+
+// makeEncMatrixForReconst computes the inverse of the survived part of
+// the encoding matrix. Cauchy matrix has a closed-form inverse, which is much
+// faster than inverting other matrices.
+func (r *RS) makeEncMatrixForReconst(survived []int) (matrix, error) {
+	if r.matrixType == CauchyMatrix {
+		return r.encMatrix.makeCauchyEncMatrixForReconst(r.gf, r.DataNum, survived)
 	}
-	return em.makeReconstMatrix(survived, needReconst)
+	return r.encMatrix.makeEncMatrixForReconst(r.gf, survived)
 }
 
-func makeInverseCacheKey(survived []int) uint64 {
-	var key uint64
-	for _, i := range survived {
-		key += 1 << uint8(i) // survived is unique/sorted, so addition is safe.
+// InverseCacheStats returns the statistics of the inverse matrix cache
+// (the shared one by default, see SetInverseCacheBudget),
+// it's all zero if the cache is disabled.
+func (r *RS) InverseCacheStats() InverseCacheStats {
+	if r.inverseCache == nil {
+		return InverseCacheStats{}
 	}
-	return key
+	return r.inverseCache.stats()
 }
 
 // Update updates parity vectors when one data vector changes.
@@ -428,24 +752,32 @@ func (r *RS) Update(oldData []byte, newData []byte, row int, parity [][]byte) (e
 		return
 	}
 
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	r.update(ws, oldData, newData, row, parity)
+	return nil
+}
+
+// update XORs the change of data vector row into parity,
+// len(parity[i]) must equal len(oldData).
+func (r *RS) update(ws *workspace, oldData []byte, newData []byte, row int, parity [][]byte) {
+
 	// Step 1: old_data XOR new_data.
-	buf := make([]byte, len(oldData))
-	xor.Encode(buf, [][]byte{oldData, newData})
+	buf := ws.getBuf(len(oldData))
+	ws.src2[0], ws.src2[1] = oldData, newData
+	xor.Encode(buf, ws.src2)
 
 	// Step 2: recalculate parity.
-	vects := make([][]byte, 1+r.ParityNum)
-	vects[0] = buf
-	gm := make([]byte, r.ParityNum)
+	gm := ws.gm[:r.ParityNum]
 	for i := 0; i < r.ParityNum; i++ {
 		col := row
 		off := i*r.DataNum + col
-		c := r.GenMatrix[off]
-		gm[i] = c
-		vects[i+1] = parity[i]
+		gm[i] = r.GenMatrix[off]
 	}
-	rs := &RS{DataNum: 1, ParityNum: r.ParityNum, GenMatrix: gm, cpuFeat: r.cpuFeat, gmu: r.gmu}
-	rs.encode(vects, true)
-	return nil
+	dv := ws.vs[:1]
+	dv[0] = buf
+	r.encodeRange(gm, dv, parity, 0, len(buf), true)
 }
 
 var (
@@ -496,36 +828,33 @@ func (r *RS) Replace(data [][]byte, replaceRows []int, parity [][]byte) (err err
 		return
 	}
 
-	d, p := r.DataNum, r.ParityNum
-	rn := len(replaceRows)
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	gm := r.makeUpdateMatrix(ws.gm, replaceRows)
+	r.encodeRange(gm, data, parity, 0, len(data[0]), true)
+	return nil
+}
 
-	// Build the generator matrix for replacement.
-	//
-	// Values in replaceRows are row indexes in data, and also column indexes
-	// in the generator matrix.
-	gm := make([]byte, p*rn)
+// makeUpdateMatrix builds the generator matrix for updating rows in data
+// (see Replace and UpdateRows) into gm, which must have room for
+// ParityNum*len(rows) bytes.
+//
+// Values in rows are row indexes in data, and also column indexes
+// in the generator matrix.
+func (r *RS) makeUpdateMatrix(gm []byte, rows []int) matrix {
+	d, p := r.DataNum, r.ParityNum
+	rn := len(rows)
+	gm = gm[:p*rn]
 	off := 0
 	for i := 0; i < p; i++ {
 		for j := 0; j < rn; j++ {
-			k := i*d + replaceRows[j]
+			k := i*d + rows[j]
 			gm[off] = r.GenMatrix[k]
 			off++
 		}
 	}
-
-	vects := make([][]byte, p+rn)
-	for i := range data {
-		vects[i] = data[i]
-	}
-
-	for i := range parity {
-		vects[rn+i] = parity[i]
-	}
-
-	updateRS := &RS{DataNum: rn, ParityNum: p,
-		GenMatrix: gm, cpuFeat: r.cpuFeat, gmu: r.gmu}
-	updateRS.encode(vects, true)
-	return nil
+	return gm
 }
 
 var (
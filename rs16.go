@@ -0,0 +1,350 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+)
+
+// RS16 is a Reed-Solomon encoder/decoder over GF(2^16) (see Polynomial16),
+// for stripes which have more than 256 vectors.
+//
+// Vectors are sequences of 16-bit little-endian symbols, so their sizes must be even.
+// The encoding matrix is identity matrix upon Cauchy matrix as RS.
+//
+// Warning:
+// Reconstructing data vectors inverts a DataNum*DataNum matrix, which costs
+// O(DataNum^3) and isn't cached, so it's slow for very wide stripes.
+type RS16 struct {
+	DataNum   int      // DataNum is the number of data row vectors.
+	ParityNum int      // ParityNum is the number of parity row vectors.
+	GenMatrix []uint16 // GenMatrix is the generator matrix (ParityNum rows, DataNum columns), it must not be modified.
+
+	cpuFeat int
+}
+
+const maxVects16 = 1 << 16
+
+var (
+	ErrIllegalVects16 = errors.New("illegal data/parity number: <= 0 or data+parity > 65536")
+	ErrOddVectSize    = errors.New("vector size must be even in GF(2^16)")
+)
+
+// New16 creates an RS16 instance with the given data and parity shard counts.
+func New16(dataNum, parityNum int) (r *RS16, err error) {
+	return new16WithFeature(dataNum, parityNum, getCPUFeature())
+}
+
+func new16WithFeature(dataNum, parityNum, feat int) (r *RS16, err error) {
+	d, p := dataNum, parityNum
+	if d <= 0 || p <= 0 || d+p > maxVects16 {
+		return nil, ErrIllegalVects16
+	}
+	initGF16()
+
+	// Cauchy matrix: 1/(i+j), where 0 <= j < d and d <= i < d+p.
+	g := make([]uint16, p*d)
+	for i := 0; i < p; i++ {
+		for j := 0; j < d; j++ {
+			g[i*d+j] = gf16Inv(uint16((d + i) ^ j))
+		}
+	}
+	return &RS16{DataNum: d, ParityNum: p, GenMatrix: g, cpuFeat: feat}, nil
+}
+
+// Encode encodes data for generating parity.
+// It multiplies generator matrix by vects[:r.DataNum] to get parity vectors,
+// and writes into vects[r.DataNum:].
+func (r *RS16) Encode(vects [][]byte) (err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	r.encode(r.GenMatrix, vects[:r.DataNum], vects[r.DataNum:], false)
+	return nil
+}
+
+func (r *RS16) checkEncode(vects [][]byte) error {
+	if len(vects) != r.DataNum+r.ParityNum {
+		return ErrMismatchVects
+	}
+	return checkVects16Size(vects)
+}
+
+// checkVects16Size checks that all vectors have the same non-zero even size.
+func checkVects16Size(vects ...[][]byte) error {
+	size := -1
+	for _, vs := range vects {
+		for _, v := range vs {
+			if size < 0 {
+				size = len(v)
+			}
+			if len(v) != size {
+				return ErrMismatchVectSize
+			}
+		}
+	}
+	if size <= 0 {
+		return ErrZeroVectSize
+	}
+	if size&1 != 0 {
+		return ErrOddVectSize
+	}
+	return nil
+}
+
+// encode computes pv (^)= g * dv chunk by chunk (see getSplitSize),
+// g has len(pv) rows and len(dv) columns.
+func (r *RS16) encode(g []uint16, dv, pv [][]byte, updateOnly bool) {
+	d := len(dv)
+	size := len(dv[0])
+	splitSize := getSplitSize(size)
+	var tbl mulTbl16 // Made for each coefficient, it's cheap compared with multiplying a chunk.
+	for start := 0; start < size; start += splitSize {
+		end := start + splitSize
+		if end > size {
+			end = size
+		}
+		for j := range pv {
+			for i := 0; i < d; i++ {
+				tbl.init(g[j*d+i])
+				mulVect16(r.cpuFeat, &tbl, dv[i][start:end], pv[j][start:end], i != 0 || updateOnly)
+			}
+		}
+	}
+}
+
+// Reconst reconstructs missing vectors,
+// arguments are the same as RS.Reconst.
+func (r *RS16) Reconst(vects [][]byte, survived, needReconst []int) (err error) {
+	if len(needReconst) == 0 {
+		return nil
+	}
+	d, p := r.DataNum, r.ParityNum
+	if len(vects) != d+p {
+		return ErrMismatchVects
+	}
+	if err = checkVectIdx(survived, d, p); err != nil {
+		return
+	}
+	if err = checkVectIdx(needReconst, d, p); err != nil {
+		return
+	}
+
+	status := make([]uint8, d+p)
+	st := vectUnknown
+	if len(survived) == 0 {
+		st = vectSurvived
+	}
+	for i := range status {
+		status[i] = st
+	}
+	for _, v := range survived {
+		status[v] = vectSurvived
+	}
+	fullDataRequired := false
+	for _, v := range needReconst {
+		status[v] = vectNeedReconst
+		if v >= d {
+			fullDataRequired = true
+		}
+	}
+	if fullDataRequired {
+		for i, v := range status[:d] {
+			if v == vectUnknown {
+				status[i] = vectNeedReconst
+			}
+		}
+	}
+	var vs, dataLost, parityLost []int
+	for i, s := range status {
+		switch s {
+		case vectSurvived:
+			vs = append(vs, i)
+		case vectNeedReconst:
+			if i < d {
+				dataLost = append(dataLost, i)
+			} else {
+				parityLost = append(parityLost, i)
+			}
+		}
+	}
+	if len(vs) < d || len(dataLost)+len(parityLost) > p {
+		return ErrTooManyLost
+	}
+	vs = vs[:d]
+
+	used := make([][]byte, 0, d+len(dataLost)+len(parityLost))
+	for _, i := range vs {
+		used = append(used, vects[i])
+	}
+	for _, i := range dataLost {
+		used = append(used, vects[i])
+	}
+	for _, i := range parityLost {
+		used = append(used, vects[i])
+	}
+	err = checkVects16Size(used)
+	if err != nil {
+		return
+	}
+
+	if len(dataLost) > 0 {
+		err = r.reconstData(vects, vs, dataLost)
+		if err != nil {
+			return
+		}
+	}
+	if len(parityLost) > 0 {
+		g := make([]uint16, len(parityLost)*d)
+		pv := make([][]byte, len(parityLost))
+		for k, i := range parityLost {
+			copy(g[k*d:k*d+d], r.GenMatrix[(i-d)*d:(i-d)*d+d])
+			pv[k] = vects[i]
+		}
+		r.encode(g, vects[:d], pv, false)
+	}
+	return nil
+}
+
+// reconstData reconstructs dataLost vectors from survived data/parity vectors.
+func (r *RS16) reconstData(vects [][]byte, survived, dataLost []int) error {
+	d := r.DataNum
+	m := make([]uint16, d*d)
+	for k, i := range survived {
+		if i < d {
+			m[k*d+i] = 1
+		} else {
+			copy(m[k*d:k*d+d], r.GenMatrix[(i-d)*d:(i-d)*d+d])
+		}
+	}
+	inv, err := invert16(m, d)
+	if err != nil {
+		return err
+	}
+
+	g := make([]uint16, len(dataLost)*d)
+	dv := make([][]byte, d)
+	pv := make([][]byte, len(dataLost))
+	for k, i := range dataLost {
+		copy(g[k*d:k*d+d], inv[i*d:i*d+d])
+		pv[k] = vects[i]
+	}
+	for k, i := range survived {
+		dv[k] = vects[i]
+	}
+	r.encode(g, dv, pv, false)
+	return nil
+}
+
+// invert16 computes the inverse of n*n matrix m in GF(2^16).
+func invert16(m []uint16, n int) (inv []uint16, err error) {
+	left := make([]uint16, n*n)
+	copy(left, m)
+	inv = make([]uint16, n*n)
+	for i := 0; i < n; i++ {
+		inv[i*n+i] = 1
+	}
+
+	for i := 0; i < n; i++ {
+		if left[i*n+i] == 0 {
+			j := i + 1
+			for ; j < n; j++ {
+				if left[j*n+i] != 0 {
+					break
+				}
+			}
+			if j == n {
+				return nil, ErrSingularMatrix
+			}
+			for k := 0; k < n; k++ {
+				left[i*n+k], left[j*n+k] = left[j*n+k], left[i*n+k]
+				inv[i*n+k], inv[j*n+k] = inv[j*n+k], inv[i*n+k]
+			}
+		}
+
+		if left[i*n+i] != 1 {
+			v := gf16Inv(left[i*n+i])
+			for k := 0; k < n; k++ {
+				left[i*n+k] = gf16Mul(left[i*n+k], v)
+				inv[i*n+k] = gf16Mul(inv[i*n+k], v)
+			}
+		}
+
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			v := left[j*n+i]
+			if v != 0 {
+				for k := 0; k < n; k++ {
+					left[j*n+k] ^= gf16Mul(v, left[i*n+k])
+					inv[j*n+k] ^= gf16Mul(v, inv[i*n+k])
+				}
+			}
+		}
+	}
+	return inv, nil
+}
+
+// Update updates parity vectors when one data vector changes,
+// arguments are the same as RS.Update.
+func (r *RS16) Update(oldData []byte, newData []byte, row int, parity [][]byte) (err error) {
+	if len(parity) != r.ParityNum {
+		return ErrMismatchParityNum
+	}
+	if row < 0 || row >= r.DataNum {
+		return ErrIllegalVectIndex
+	}
+	err = checkVects16Size([][]byte{oldData, newData}, parity)
+	if err != nil {
+		return
+	}
+
+	delta := make([]byte, len(oldData))
+	for i := range delta {
+		delta[i] = oldData[i] ^ newData[i]
+	}
+	g := make([]uint16, r.ParityNum)
+	for j := range g {
+		g[j] = r.GenMatrix[j*r.DataNum+row]
+	}
+	r.encode(g, [][]byte{delta}, parity, true)
+	return nil
+}
+
+// Replace swaps oldData vectors with zero vectors, or swaps zero vectors with newData,
+// arguments are the same as RS.Replace.
+func (r *RS16) Replace(data [][]byte, replaceRows []int, parity [][]byte) (err error) {
+	if len(data) > r.DataNum {
+		return ErrTooManyReplace
+	}
+	if len(replaceRows) != len(data) {
+		return ErrMismatchReplace
+	}
+	if len(parity) != r.ParityNum {
+		return ErrMismatchParityNum
+	}
+	for _, rr := range replaceRows {
+		if rr < 0 || rr >= r.DataNum {
+			return ErrIllegalVectIndex
+		}
+	}
+	err = checkVects16Size(data, parity)
+	if err != nil {
+		return
+	}
+
+	rn := len(replaceRows)
+	g := make([]uint16, r.ParityNum*rn)
+	for j := 0; j < r.ParityNum; j++ {
+		for k, row := range replaceRows {
+			g[j*rn+k] = r.GenMatrix[j*r.DataNum+row]
+		}
+	}
+	r.encode(g, data, parity, true)
+	return nil
+}
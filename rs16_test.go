@@ -0,0 +1,227 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func makeVects16(d, p, size int) [][]byte {
+	vects := make([][]byte, d+p)
+	for i := range vects {
+		vects[i] = make([]byte, size)
+	}
+	for i := 0; i < d; i++ {
+		fillRandom(vects[i])
+	}
+	return vects
+}
+
+func TestRS16_Encode(t *testing.T) {
+	if getCPUFeature() != featAVX2 {
+		t.Skip("no SIMD feature detected, skip comparing encoding results with no-SIMD implementation")
+	}
+	for _, dp := range [][2]int{{10, 4}, {300, 20}} {
+		d, p := dp[0], dp[1]
+		r, err := new16WithFeature(d, p, featAVX2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cr, err := new16WithFeature(d, p, featNoSIMD)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, size := range []int{2, 30, 32, 34, 1000, 64*kib + 2} {
+			exp := makeVects16(d, p, size)
+			act := make([][]byte, d+p)
+			for i := range act {
+				act[i] = make([]byte, size)
+				copy(act[i], exp[i])
+			}
+			err = cr.Encode(exp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = r.Encode(act)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range exp {
+				if !bytes.Equal(exp[i], act[i]) {
+					t.Fatalf("%d+%d mismatched with no-SIMD, size: %d, vect: %d", d, p, size, i)
+				}
+			}
+		}
+	}
+}
+
+// TestRS16_EncodeRef compares encoding results with computing
+// GenMatrix * data symbol by symbol, it runs without SIMD too.
+func TestRS16_EncodeRef(t *testing.T) {
+	feats := []int{featNoSIMD}
+	if getCPUFeature() == featAVX2 {
+		feats = append(feats, featAVX2)
+	}
+	for _, feat := range feats {
+		for _, dp := range [][2]int{{1, 1}, {10, 4}, {300, 20}} {
+			d, p := dp[0], dp[1]
+			r, err := new16WithFeature(d, p, feat)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, size := range []int{2, 34, 1000} {
+				vects := makeVects16(d, p, size)
+				err = r.Encode(vects)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for j := 0; j < p; j++ {
+					exp := make([]byte, size)
+					for k := 0; k < size; k += 2 {
+						var v uint16
+						for i := 0; i < d; i++ {
+							v ^= gf16Mul(r.GenMatrix[j*d+i], uint16(vects[i][k])|uint16(vects[i][k+1])<<8)
+						}
+						exp[k], exp[k+1] = byte(v), byte(v>>8)
+					}
+					if !bytes.Equal(exp, vects[d+j]) {
+						t.Fatalf("%s %d+%d mismatched with reference, size: %d, parity: %d",
+							featToStr(feat), d, p, size, j)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestRS16_Reconst(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	testReconst16(t, 10, 4, 1000, 64)
+	testReconst16(t, 300, 20, 64, 8) // Wider than GF(2^8).
+}
+
+func testReconst16(t *testing.T, d, p, size, loop int) {
+	r, err := New16(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < loop; i++ {
+		exp := makeVects16(d, p, size)
+		err = r.Encode(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		act := make([][]byte, d+p)
+		for j := range act {
+			act[j] = make([]byte, size)
+		}
+		survived, needReconst := genIdxForTest(d, p, rand.Intn(d+p), rand.Intn(p+1))
+		for _, j := range survived {
+			copy(act[j], exp[j])
+		}
+		err = r.Reconst(act, survived, needReconst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, j := range needReconst {
+			if !bytes.Equal(exp[j], act[j]) {
+				t.Fatalf("%d+%d mismatched vect: %d, size: %d", d, p, j, size)
+			}
+		}
+	}
+}
+
+func TestRS16_Update(t *testing.T) {
+	d, p, size := 10, 4, 1000
+	r, err := New16(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects := makeVects16(d, p, size)
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := rand.Intn(d)
+	newData := make([]byte, size)
+	fillRandom(newData)
+	err = r.Update(vects[row], newData, row, vects[d:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(vects[row], newData)
+
+	// Replace data vector row with zero vector.
+	err = r.Replace([][]byte{vects[row]}, []int{row}, vects[d:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range vects[row] {
+		vects[row][i] = 0
+	}
+
+	exp := makeVects16(d, p, size)
+	for i := 0; i < d; i++ {
+		copy(exp[i], vects[i])
+	}
+	err = r.Encode(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := d; i < d+p; i++ {
+		if !bytes.Equal(exp[i], vects[i]) {
+			t.Fatalf("parity mismatched: %d", i)
+		}
+	}
+}
+
+func TestRS16_Illegal(t *testing.T) {
+	_, err := New16(maxVects16, 1)
+	if err != ErrIllegalVects16 {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVects16, err)
+	}
+	r, err := New16(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Encode(makeVects16(3, 2, 3))
+	if err != ErrOddVectSize {
+		t.Fatalf("exp: %v, got: %v", ErrOddVectSize, err)
+	}
+	err = r.Reconst(makeVects16(3, 2, 2), []int{0, 1}, []int{2, 3, 4})
+	if err != ErrTooManyLost {
+		t.Fatalf("exp: %v, got: %v", ErrTooManyLost, err)
+	}
+}
+
+func BenchmarkRS16_Encode(b *testing.B) {
+	size := 8 * kib
+	for _, dp := range [][2]int{{10, 4}, {300, 20}} {
+		d, p := dp[0], dp[1]
+		b.Run(fmt.Sprintf("(%d+%d)-%s-%s", d, p, byteToStr(size), featToStr(getCPUFeature())),
+			func(b *testing.B) {
+				r, err := New16(d, p)
+				if err != nil {
+					b.Fatal(err)
+				}
+				vects := makeVects16(d, p, size)
+				b.SetBytes(int64((d + p) * size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err = r.Encode(vects)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+	}
+}
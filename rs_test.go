@@ -7,10 +7,9 @@ package reedsolomon
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"math"
 	"math/rand"
-	"sync"
 	"testing"
 	"time"
 )
@@ -62,7 +61,7 @@ func (m matrix) mul(vects [][]byte, input, output, n int) {
 		for j := 0; j < n; j++ {
 			var s uint8
 			for k := 0; k < input; k++ {
-				s ^= gfMul(src[k][j], m[i*input+k])
+				s ^= defaultGF.mul(src[k][j], m[i*input+k])
 			}
 			out[i][j] = s
 		}
@@ -136,41 +135,16 @@ func testEncode(t *testing.T, d, p, maxSize, feat, cmpFeat int) {
 		fs, cmpfs, d, p, maxSize+1)
 }
 
-func TestMakeInverseCacheKey(t *testing.T) {
-
-	type tc struct {
-		survived []int
-		exp      uint64
-	}
-	cases := []tc{
-		{[]int{0}, 1},
-		{[]int{1}, 2},
-		{[]int{0, 1}, 3},
-		{[]int{0, 1, 2}, 7},
-		{[]int{0, 2}, 5},
-	}
-	survived := make([]int, 64)
-	for i := range survived {
-		survived[i] = i
-	}
-	cases = append(cases, tc{survived, math.MaxUint64})
-	for i, c := range cases {
-		got := makeInverseCacheKey(c.survived)
-		if got != c.exp {
-			t.Fatalf("case: %d, exp: %d, got: %d, survived: %#v", i, c.exp, got, c.survived)
-		}
-	}
-}
-
 func TestRS_Reconst(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 
 	testReconst(t, testDataNum, testParityNum, testSize, 128)
+	testReconst(t, testDataNum, testParityNum, testSize, 128, WithMatrix(VandermondeMatrix))
 }
 
-func testReconst(t *testing.T, d, p, size, loop int) {
+func testReconst(t *testing.T, d, p, size, loop int, opts ...Option) {
 
-	r, err := New(d, p)
+	r, err := New(d, p, opts...)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -216,10 +190,86 @@ func testReconst(t *testing.T, d, p, size, loop int) {
 	}
 }
 
+func TestRS_ReconstInto(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	testReconstInto(t, testDataNum, testParityNum, testSize, 128)
+}
+
+func testReconstInto(t *testing.T, d, p, size, loop int) {
+
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < loop; i++ {
+
+		exp := make([][]byte, d+p)
+		for j := range exp {
+			exp[j] = make([]byte, size)
+		}
+		for j := 0; j < d; j++ {
+			fillRandom(exp[j])
+		}
+		err = r.Encode(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		survived, needReconst := genIdxForTest(d, p, rand.Intn(d+p), rand.Intn(p)+1)
+		src := make([][]byte, d+p)
+		for _, j := range survived {
+			src[j] = make([]byte, size)
+			copy(src[j], exp[j])
+		}
+		dst := make(map[int][]byte)
+		for _, j := range needReconst {
+			dst[j] = make([]byte, size)
+		}
+
+		if rand.Intn(2) == 0 {
+			survived = nil // All non-nil vectors are survived.
+		}
+		err = r.ReconstInto(src, survived, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j, v := range src {
+			if v != nil && !bytes.Equal(exp[j], v) {
+				t.Fatalf("survived vect modified: %d", j)
+			}
+		}
+		for j, v := range dst {
+			if !bytes.Equal(exp[j], v) {
+				t.Fatalf("mismatched vect: %d, size: %d", j, size)
+			}
+		}
+	}
+}
+
+func TestRS_ReconstIntoTooManyLost(t *testing.T) {
+	d, p, size := testDataNum, testParityNum, 16
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := make([][]byte, d+p)
+	for j := 0; j < d-1; j++ {
+		src[j] = make([]byte, size)
+	}
+	err = r.ReconstInto(src, nil, map[int][]byte{d - 1: make([]byte, size)})
+	if err != ErrTooManyLost {
+		t.Fatalf("exp: %v, got: %v", ErrTooManyLost, err)
+	}
+}
+
 func TestRS_Update(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 
 	testUpdate(t, testDataNum, testParityNum, testSize)
+	testUpdate(t, testDataNum, testParityNum, 64*kib+20) // Multi-chunk with a tail which isn't 16 bytes aligned.
 }
 
 func testUpdate(t *testing.T, d, p, size int) {
@@ -352,16 +402,14 @@ func makeReplaceRowRandom(d int) []int {
 	return s
 }
 
-func TestRS_getReconstMatrixFromCache(t *testing.T) {
+func TestRS_getEncMatrixForReconstFromCache(t *testing.T) {
 	d, p := 64, 64 // Big enough for showing cache effects.
 	r, err := New(d, p)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// Enable cache.
-	r.inverseCacheEnabled = true
-	r.inverseCache = new(sync.Map)
-	r.inverseCacheMax = 1
+	r.inverseCache = newInverseCache(d * d)
 
 	rand.Seed(time.Now().UnixNano())
 
@@ -370,7 +418,7 @@ func TestRS_getReconstMatrixFromCache(t *testing.T) {
 	for {
 		var needReconstData int
 		survived, needReconst = genIdxForTest(d, p, d, p)
-		survived, needReconst, needReconstData, err = r.checkReconst(survived, needReconst)
+		survived, needReconst, needReconstData, err = r.checkReconst(r.getWorkspace(), survived, needReconst)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -381,14 +429,14 @@ func TestRS_getReconstMatrixFromCache(t *testing.T) {
 	}
 
 	start1 := time.Now()
-	exp, err := r.getReconstMatrix(survived, needReconst)
+	exp, err := r.getReconstMatrix(nil, survived, needReconst)
 	if err != nil {
 		t.Fatal(err)
 	}
 	cost1 := time.Now().Sub(start1)
 
 	start2 := time.Now()
-	act, err := r.getReconstMatrix(survived, needReconst)
+	act, err := r.getReconstMatrix(nil, survived, needReconst)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -403,6 +451,66 @@ func TestRS_getReconstMatrixFromCache(t *testing.T) {
 	}
 }
 
+// Cache keys must be distinct for survived vectors beyond the 64th,
+// or different stripes share (wrong) inverse matrices.
+func TestRS_InverseCacheWide(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	dps := [][]int{
+		{40, 25},
+		{64, 64},
+		{100, 20},
+		{200, 56},
+		{255, 1},
+	}
+	for _, dp := range dps {
+		d, p := dp[0], dp[1]
+		testInverseCacheWide(t, d, p)
+		testReconst(t, d, p, 64, 16)
+	}
+}
+
+func testInverseCacheWide(t *testing.T, d, p int) {
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.inverseCache == nil {
+		t.Fatalf("(%d+%d): inverse cache should be enabled", d, p)
+	}
+
+	// Survived sets which are only different in vectors >= 64.
+	survived := make([]int, d)
+	for i := range survived {
+		survived[i] = i
+	}
+	for i := 0; i < p && i < 4; i++ {
+		survived[d-1] = d + i
+		key := makeInverseCacheKey(survived)
+		exp, err := r.getEncMatrixForReconst(survived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.inverseCache.contains(r.layout, key) {
+			t.Fatalf("(%d+%d): inverse matrix isn't cached, survived: %v", d, p, survived)
+		}
+		act, err := r.getEncMatrixForReconst(survived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(exp, act) {
+			t.Fatalf("(%d+%d): cache matrix mismatched", d, p)
+		}
+		em, err := r.encMatrix.makeEncMatrixForReconst(r.gf, survived)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(em, act) {
+			t.Fatalf("(%d+%d): cache matrix mismatched with inverted, survived: %v", d, p, survived)
+		}
+	}
+}
+
 func BenchmarkRS_Encode(b *testing.B) {
 	dps := [][]int{
 		{10, 2},
@@ -487,6 +595,7 @@ func benchReconst(b *testing.B, d, p, size int, survived, needReconst []int) {
 	}
 
 	b.SetBytes(int64((d + len(needReconst)) * size))
+	b.ReportAllocs() // Expect 0 allocs/op, see TestRS_ZeroAlloc.
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		err = r.Reconst(vects, survived, needReconst)
@@ -507,13 +616,14 @@ func BenchmarkRS_checkReconst(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
+		ws := r.getWorkspace()
 		for i := 1; i <= p; i++ {
 			is, ir := genIdxForTest(d, p, d, i)
 			b.Run(fmt.Sprintf("d:%d,p:%d,survived:%d,need_reconst:%d", d, p, len(is), len(ir)),
 				func(b *testing.B) {
 					b.ResetTimer()
 					for j := 0; j < b.N; j++ {
-						_, _, _, err = r.checkReconst(is, ir)
+						_, _, _, err = r.checkReconst(ws, is, ir)
 						if err != nil {
 							b.Fatal(err)
 						}
@@ -554,6 +664,7 @@ func benchUpdate(b *testing.B, d, p, size, updateRow int) {
 	fillRandom(newData)
 
 	b.SetBytes(int64((p + 2 + p) * size))
+	b.ReportAllocs() // Expect 0 allocs/op, see TestRS_ZeroAlloc.
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		err = r.Update(vects[updateRow], newData, updateRow, vects[d:])
@@ -596,6 +707,7 @@ func benchReplace(b *testing.B, d, p, size, n int) {
 		updateRows[i] = i
 	}
 	b.SetBytes(int64((n + p + p) * size))
+	b.ReportAllocs() // Expect 0 allocs/op, see TestRS_ZeroAlloc.
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		err = r.Replace(vects[:n], updateRows, vects[d:])
@@ -604,3 +716,280 @@ func benchReplace(b *testing.B, d, p, size, n int) {
 		}
 	}
 }
+
+func TestRS_Concurrency(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	size := 4*minParallelSize + 20 // Multi-goroutine with an unaligned tail.
+
+	seq, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	par, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	par.SetConcurrency(4)
+
+	exp := make([][]byte, d+p)
+	act := make([][]byte, d+p)
+	for j := range exp {
+		exp[j], act[j] = make([]byte, size), make([]byte, size)
+	}
+	for j := 0; j < d; j++ {
+		fillRandom(exp[j])
+		copy(act[j], exp[j])
+	}
+	checkEqual := func(op string) {
+		for j := range exp {
+			if !bytes.Equal(exp[j], act[j]) {
+				t.Fatalf("%s mismatched: vect: %d", op, j)
+			}
+		}
+	}
+
+	if err = seq.Encode(exp); err != nil {
+		t.Fatal(err)
+	}
+	if err = par.Encode(act); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual("encode")
+
+	survived, needReconst := genIdxForTest(d, p, d, p)
+	for _, j := range needReconst {
+		fillRandom(act[j])
+	}
+	if err = par.Reconst(act, survived, needReconst); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual("reconst")
+
+	row := rand.Intn(d)
+	newData := make([]byte, size)
+	fillRandom(newData)
+	if err = seq.Update(exp[row], newData, row, exp[d:]); err != nil {
+		t.Fatal(err)
+	}
+	if err = par.Update(act[row], newData, row, act[d:]); err != nil {
+		t.Fatal(err)
+	}
+	copy(exp[row], newData)
+	copy(act[row], newData)
+	checkEqual("update")
+
+	rows := []int{0, d - 1}
+	data := [][]byte{exp[0], exp[d-1]}
+	if err = seq.Replace(data, rows, exp[d:]); err != nil {
+		t.Fatal(err)
+	}
+	if err = par.Replace(data, rows, act[d:]); err != nil {
+		t.Fatal(err)
+	}
+	checkEqual("replace")
+}
+
+func BenchmarkRS_EncodeConcurrency(b *testing.B) {
+	d, p := 10, 4
+	size := 16 * mib
+
+	for _, n := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("(%d+%d)-%s-goroutines_%d-%s", d, p, byteToStr(size), n, featToStr(getCPUFeature())),
+			func(b *testing.B) {
+				vects := make([][]byte, d+p)
+				for j := range vects {
+					vects[j] = make([]byte, size)
+				}
+				for j := 0; j < d; j++ {
+					fillRandom(vects[j])
+				}
+				r, err := New(d, p)
+				if err != nil {
+					b.Fatal(err)
+				}
+				r.SetConcurrency(n)
+
+				b.SetBytes(int64((d + p) * size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err = r.Encode(vects)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+	}
+}
+
+func TestRS_ZeroAlloc(t *testing.T) {
+	if raceEnabled {
+		t.Skip("skip allocation test with race detector")
+	}
+
+	d, p, size := testDataNum, testParityNum, 8*kib
+
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects := make([][]byte, d+p)
+	for j := range vects {
+		vects[j] = make([]byte, size)
+	}
+	for j := 0; j < d; j++ {
+		fillRandom(vects[j])
+	}
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	survived, needReconst := genIdxForTest(d, p, d, p)
+	newData := make([]byte, size)
+	fillRandom(newData)
+	replaceRows := []int{0, 1}
+
+	cases := []struct {
+		name string
+		f    func()
+	}{
+		{"Reconst", func() { _ = r.Reconst(vects, survived, needReconst) }},
+		{"ReconstRange", func() { _ = r.ReconstRange(vects, survived, needReconst, 16, size/2) }},
+		{"Update", func() { _ = r.Update(vects[0], newData, 0, vects[d:]) }},
+		{"UpdateRange", func() { _ = r.UpdateRange(vects[0][16:32], newData[:16], 0, 16, vects[d:]) }},
+		{"Replace", func() { _ = r.Replace(vects[:2], replaceRows, vects[d:]) }},
+		{"UpdateRows", func() { _ = r.UpdateRows(replaceRows, vects[:2], vects[2:4], vects[d:]) }},
+	}
+	for _, c := range cases {
+		c.f() // Warm up workspace and inverse matrix cache.
+		if n := testing.AllocsPerRun(100, c.f); n != 0 {
+			t.Fatalf("%s allocates: %.f allocs/op", c.name, n)
+		}
+	}
+}
+
+// Golden vectors from Backblaze's JavaReedSolomon (ReedSolomonTest.testOneEncode),
+// which are also used by klauspost/reedsolomon.
+func TestRS_VandermondeCompat(t *testing.T) {
+	r, err := New(5, 5, WithMatrix(VandermondeMatrix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects := [][]byte{
+		{0, 1}, {4, 5}, {2, 3}, {6, 7}, {8, 9},
+		make([]byte, 2), make([]byte, 2), make([]byte, 2), make([]byte, 2), make([]byte, 2),
+	}
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := [][]byte{{12, 13}, {10, 11}, {14, 15}, {90, 91}, {94, 95}}
+	for i, e := range exp {
+		if !bytes.Equal(e, vects[5+i]) {
+			t.Fatalf("parity %d mismatched, exp: %v, got: %v", i, e, vects[5+i])
+		}
+	}
+
+	// Lose 5 vectors (3 data, 2 parity).
+	survived, needReconst := []int{1, 3, 5, 6, 8}, []int{0, 2, 4, 7, 9}
+	act := make([][]byte, len(vects))
+	for i := range act {
+		act[i] = make([]byte, 2)
+	}
+	for _, i := range survived {
+		copy(act[i], vects[i])
+	}
+	err = r.Reconst(act, survived, needReconst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range vects {
+		if !bytes.Equal(vects[i], act[i]) {
+			t.Fatalf("vect %d mismatched", i)
+		}
+	}
+}
+
+func TestNewWithMatrix(t *testing.T) {
+	d, p := testDataNum, testParityNum
+
+	gen := makeVandermondeEncodeMatrix(defaultGF, d, p)[d*d:]
+	r, err := NewWithMatrix(d, p, gen, WithMDSCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MatrixType() != CustomMatrix {
+		t.Fatal("matrix type should be CustomMatrix")
+	}
+	r2, err := New(d, p, WithMatrix(VandermondeMatrix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r.encMatrix, r2.encMatrix) {
+		t.Fatal("encoding matrix mismatched")
+	}
+	gen[0] ^= 1 // gen should be copied.
+	if bytes.Equal(r.GenMatrix, gen) {
+		t.Fatal("generator matrix should be copied")
+	}
+
+	// Two equal parity rows can't reconstruct two lost data vectors.
+	gen = []byte{
+		1, 1, 1,
+		1, 1, 1,
+	}
+	_, err = NewWithMatrix(3, 2, gen, WithMDSCheck())
+	if !errors.Is(err, ErrSingularMatrix) {
+		t.Fatalf("exp: %v, got: %v", ErrSingularMatrix, err)
+	}
+	var e *NotMDSError
+	if !errors.As(err, &e) || fmt.Sprint(e.Survived) != "[0 3 4]" {
+		t.Fatalf("first singular combination mismatched: %v", err)
+	}
+	_, err = NewWithMatrix(3, 2, gen) // No check.
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewWithMatrix(3, 2, gen[:5])
+	if err != ErrIllegalGenMatrix {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalGenMatrix, err)
+	}
+	_, err = New(3, 2, WithMatrix(CustomMatrix))
+	if err != ErrUnknownMatrix {
+		t.Fatalf("exp: %v, got: %v", ErrUnknownMatrix, err)
+	}
+}
+
+func TestRS_Polynomial(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	for _, poly := range []int{0x11b, 0x187} {
+		testReconst(t, d, p, testSize, 32, WithPolynomial(poly))
+		testReconst(t, d, p, testSize, 32, WithPolynomial(poly), WithMatrix(VandermondeMatrix))
+		testReconst(t, d, p, testSize, 32, WithPolynomial(poly), WithCPUFeature(NoSIMD))
+	}
+
+	r, err := New(d, p, WithPolynomial(0x11b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Polynomial() != 0x11b {
+		t.Fatal("polynomial mismatched")
+	}
+	r2, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(r.GenMatrix, r2.GenMatrix) {
+		t.Fatal("generator matrices of different polynomials should be different")
+	}
+
+	_, err = New(d, p, WithPolynomial(0x1d))
+	if err != ErrIllegalPolynomial {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalPolynomial, err)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Shard file format (version 1), all integers are little endian:
+//
+//	0:4   magic "RSSF"
+//	4     version
+//	5     matrix type
+//	6:8   DataNum
+//	8:10  ParityNum
+//	10:12 shard index
+//	12:16 block size
+//	16:24 object size
+//	24:26 polynomial of GF(2^8)
+//	26:28 reserved (zero)
+//	28:32 CRC32C of the encoding matrix
+//	32:36 CRC32C of header[0:32]
+//
+// Header is followed by the shard's vectors, laid out as in Stream.
+// Each vector is followed by the CRC32C of it. All vectors have
+// block size bytes except the last one, which may be shorter.
+const (
+	ShardFormatVersion = 1
+	shardMagic         = "RSSF"
+	shardHeaderSize    = 36
+	shardCRCSize       = 4
+)
+
+var crc32cTbl = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	ErrNotShardFile      = errors.New("not a shard file")
+	ErrShardVersion      = errors.New("unsupported shard file version")
+	ErrShardChecksum     = errors.New("shard checksum mismatch")
+	ErrShardMismatch     = errors.New("shard mismatch")
+	ErrIllegalShardIndex = errors.New("illegal shard index")
+)
+
+// ShardHeader describes a shard file.
+type ShardHeader struct {
+	DataNum    int
+	ParityNum  int
+	Index      int // Index is the index of the shard in vects.
+	Matrix     MatrixType
+	Polynomial int // Polynomial is the polynomial of GF(2^8), see WithPolynomial.
+	// MatrixChecksum is the CRC32C of the encoding matrix, see RS.MatrixChecksum.
+	// It tells different generator matrices of CustomMatrix apart.
+	MatrixChecksum uint32
+	ObjectSize     int64 // ObjectSize is the size of the original object.
+	BlockSize      int   // BlockSize is the size of each vector in a full block, see Stream.
+}
+
+// ShardHeader returns the header of shard index,
+// which is made by s from an object with objectSize bytes.
+func (s *Stream) ShardHeader(index int, objectSize int64) ShardHeader {
+	return ShardHeader{
+		DataNum:        s.rs.DataNum,
+		ParityNum:      s.rs.ParityNum,
+		Index:          index,
+		Matrix:         s.rs.matrixType,
+		Polynomial:     s.rs.gf.poly,
+		MatrixChecksum: s.rs.MatrixChecksum(),
+		ObjectSize:     objectSize,
+		BlockSize:      s.blockSize,
+	}
+}
+
+// ShardSize returns the size of the shard (without header and checksums).
+func (h *ShardHeader) ShardSize() int64 {
+	full := int64(h.DataNum) * int64(h.BlockSize)
+	rem := h.ObjectSize % full
+	return h.ObjectSize/full*int64(h.BlockSize) + (rem+int64(h.DataNum)-1)/int64(h.DataNum)
+}
+
+// Check checks whether the shard could be encoded/decoded by r.
+func (h *ShardHeader) Check(r *RS) error {
+	mc := r.MatrixChecksum()
+	if h.DataNum != r.DataNum || h.ParityNum != r.ParityNum || h.Matrix != r.matrixType ||
+		h.Polynomial != r.gf.poly || h.MatrixChecksum != mc {
+		return fmt.Errorf("%w: shard is %d+%d (matrix: %d %#08x, polynomial: %#x), codec is %d+%d (matrix: %d %#08x, polynomial: %#x)",
+			ErrShardMismatch, h.DataNum, h.ParityNum, h.Matrix, h.MatrixChecksum, h.Polynomial,
+			r.DataNum, r.ParityNum, r.matrixType, mc, r.gf.poly)
+	}
+	return h.check()
+}
+
+// SameObject checks whether h and o are shards of the same object
+// (they may be different shards).
+func (h *ShardHeader) SameObject(o *ShardHeader) error {
+	if h.DataNum != o.DataNum || h.ParityNum != o.ParityNum || h.Matrix != o.Matrix ||
+		h.Polynomial != o.Polynomial || h.MatrixChecksum != o.MatrixChecksum ||
+		h.ObjectSize != o.ObjectSize || h.BlockSize != o.BlockSize {
+		return fmt.Errorf("%w: shard %d and shard %d have different headers", ErrShardMismatch, h.Index, o.Index)
+	}
+	return nil
+}
+
+func (h *ShardHeader) check() error {
+	if h.DataNum <= 0 || h.ParityNum <= 0 || h.DataNum+h.ParityNum > maxVects {
+		return ErrIllegalVects
+	}
+	if h.Index < 0 || h.Index >= h.DataNum+h.ParityNum {
+		return ErrIllegalShardIndex
+	}
+	if h.Polynomial < 0x100 || h.Polynomial > 0x1ff {
+		return ErrIllegalPolynomial
+	}
+	if h.BlockSize <= 0 || uint64(h.BlockSize) > 1<<32-1 || h.ObjectSize < 0 {
+		return ErrIllegalSize
+	}
+	return nil
+}
+
+func (h *ShardHeader) marshal(b []byte) {
+	copy(b[0:4], shardMagic)
+	b[4] = ShardFormatVersion
+	b[5] = byte(h.Matrix)
+	binary.LittleEndian.PutUint16(b[6:8], uint16(h.DataNum))
+	binary.LittleEndian.PutUint16(b[8:10], uint16(h.ParityNum))
+	binary.LittleEndian.PutUint16(b[10:12], uint16(h.Index))
+	binary.LittleEndian.PutUint32(b[12:16], uint32(h.BlockSize))
+	binary.LittleEndian.PutUint64(b[16:24], uint64(h.ObjectSize))
+	binary.LittleEndian.PutUint16(b[24:26], uint16(h.Polynomial))
+	binary.LittleEndian.PutUint16(b[26:28], 0)
+	binary.LittleEndian.PutUint32(b[28:32], h.MatrixChecksum)
+	binary.LittleEndian.PutUint32(b[32:36], crc32.Checksum(b[:32], crc32cTbl))
+}
+
+func (h *ShardHeader) unmarshal(b []byte) error {
+	if string(b[0:4]) != shardMagic {
+		return ErrNotShardFile
+	}
+	if binary.LittleEndian.Uint32(b[32:36]) != crc32.Checksum(b[:32], crc32cTbl) {
+		return fmt.Errorf("%w: header", ErrShardChecksum)
+	}
+	if b[4] != ShardFormatVersion {
+		return ErrShardVersion
+	}
+	h.Matrix = MatrixType(b[5])
+	h.DataNum = int(binary.LittleEndian.Uint16(b[6:8]))
+	h.ParityNum = int(binary.LittleEndian.Uint16(b[8:10]))
+	h.Index = int(binary.LittleEndian.Uint16(b[10:12]))
+	h.BlockSize = int(binary.LittleEndian.Uint32(b[12:16]))
+	h.ObjectSize = int64(binary.LittleEndian.Uint64(b[16:24]))
+	h.Polynomial = int(binary.LittleEndian.Uint16(b[24:26]))
+	h.MatrixChecksum = binary.LittleEndian.Uint32(b[28:32])
+	return h.check()
+}
+
+// ShardWriter writes a shard file.
+// It buffers a block, so Close must be called after all data written.
+type ShardWriter struct {
+	w    io.Writer
+	h    ShardHeader
+	size int64 // Expected shard size.
+
+	n   int64  // Bytes written by Write.
+	buf []byte // Block and its checksum.
+	bn  int    // Bytes buffered in buf.
+}
+
+// NewShardWriter writes header h into w, and returns a ShardWriter for
+// writing the shard's content.
+func NewShardWriter(w io.Writer, h ShardHeader) (sw *ShardWriter, err error) {
+	err = h.check()
+	if err != nil {
+		return
+	}
+	b := make([]byte, shardHeaderSize)
+	h.marshal(b)
+	_, err = w.Write(b)
+	if err != nil {
+		return
+	}
+
+	sw = &ShardWriter{w: w, h: h, size: h.ShardSize()}
+	bs := int64(h.BlockSize)
+	if sw.size < bs {
+		bs = sw.size
+	}
+	sw.buf = make([]byte, bs+shardCRCSize)
+	return
+}
+
+// Write writes p into shard, the total size can't be bigger than
+// the ShardSize of the header.
+func (sw *ShardWriter) Write(p []byte) (n int, err error) {
+	if sw.n+int64(len(p)) > sw.size {
+		return 0, fmt.Errorf("%w: shard is bigger than %d bytes", ErrShardMismatch, sw.size)
+	}
+	for len(p) > 0 {
+		c := copy(sw.buf[sw.bn:len(sw.buf)-shardCRCSize], p)
+		sw.bn += c
+		sw.n += int64(c)
+		n += c
+		p = p[c:]
+		if sw.bn == len(sw.buf)-shardCRCSize {
+			err = sw.flush()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Close writes the last block. It doesn't close the underlying writer.
+func (sw *ShardWriter) Close() error {
+	if sw.n != sw.size {
+		return fmt.Errorf("%w: shard has %d bytes, header wants %d", ErrShardMismatch, sw.n, sw.size)
+	}
+	if sw.bn > 0 {
+		return sw.flush()
+	}
+	return nil
+}
+
+func (sw *ShardWriter) flush() error {
+	binary.LittleEndian.PutUint32(sw.buf[sw.bn:], crc32.Checksum(sw.buf[:sw.bn], crc32cTbl))
+	_, err := sw.w.Write(sw.buf[:sw.bn+shardCRCSize])
+	sw.bn = 0
+	return err
+}
+
+// ShardReader reads a shard file, and checks every block's checksum.
+type ShardReader struct {
+	r    io.Reader
+	h    ShardHeader
+	size int64 // Shard size which is not read from r yet.
+
+	block int    // Index of the next block.
+	buf   []byte // Block and its checksum.
+	data  []byte // Unread data in buf.
+}
+
+// NewShardReader reads the header from r, and returns a ShardReader for
+// reading the shard's content.
+// The header should be checked (see ShardHeader.Check) before decoding.
+func NewShardReader(r io.Reader) (sr *ShardReader, err error) {
+	b := make([]byte, shardHeaderSize)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotShardFile
+		}
+		return
+	}
+	sr = &ShardReader{r: r}
+	err = sr.h.unmarshal(b)
+	if err != nil {
+		return nil, err
+	}
+	sr.size = sr.h.ShardSize()
+	bs := int64(sr.h.BlockSize)
+	if sr.size < bs {
+		bs = sr.size
+	}
+	sr.buf = make([]byte, bs+shardCRCSize)
+	return
+}
+
+// Header returns the header of the shard.
+func (sr *ShardReader) Header() ShardHeader {
+	return sr.h
+}
+
+// Read reads the shard's content. It returns an error wrapping
+// ErrShardChecksum if a block is corrupted, or io.ErrUnexpectedEOF
+// if the shard is truncated.
+func (sr *ShardReader) Read(p []byte) (n int, err error) {
+	if len(sr.data) == 0 {
+		if sr.size == 0 {
+			return 0, io.EOF
+		}
+		err = sr.next()
+		if err != nil {
+			return
+		}
+	}
+	n = copy(p, sr.data)
+	sr.data = sr.data[n:]
+	return
+}
+
+// next reads the next block into sr.data.
+func (sr *ShardReader) next() error {
+	bn := int64(len(sr.buf) - shardCRCSize)
+	if sr.size < bn {
+		bn = sr.size
+	}
+	b := sr.buf[:bn+shardCRCSize]
+	_, err := io.ReadFull(sr.r, b)
+	if err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if binary.LittleEndian.Uint32(b[bn:]) != crc32.Checksum(b[:bn], crc32cTbl) {
+		return fmt.Errorf("%w: shard %d, block %d", ErrShardChecksum, sr.h.Index, sr.block)
+	}
+	sr.block++
+	sr.size -= bn
+	sr.data = b[:bn]
+	return nil
+}
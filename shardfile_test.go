@@ -0,0 +1,295 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// makeShardFiles encodes obj into shard files by Stream.
+func makeShardFiles(t *testing.T, s *Stream, obj []byte) [][]byte {
+	t.Helper()
+
+	n := s.rs.DataNum + s.rs.ParityNum
+	bufs := make([]*bytes.Buffer, n)
+	sws := make([]*ShardWriter, n)
+	dst := make([]io.Writer, n)
+	for i := range dst {
+		bufs[i] = new(bytes.Buffer)
+		sw, err := NewShardWriter(bufs[i], s.ShardHeader(i, int64(len(obj))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sws[i], dst[i] = sw, sw
+	}
+	_, err := s.Encode(bytes.NewReader(obj), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make([][]byte, n)
+	for i, sw := range sws {
+		err = sw.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[i] = bufs[i].Bytes()
+	}
+	return files
+}
+
+func TestShardFile(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objSize := range []int{0, 1, d*blockSize - 1, d * blockSize, 3*d*blockSize + 7} {
+		obj := make([]byte, objSize)
+		fillRandom(obj)
+		exp := makeStreamShards(t, r, obj, blockSize)
+		files := makeShardFiles(t, s, obj)
+
+		for i, f := range files {
+			sr, err := NewShardReader(bytes.NewReader(f))
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := sr.Header()
+			if h.Index != i || h.ObjectSize != int64(objSize) || h.BlockSize != blockSize {
+				t.Fatalf("header mismatched: %+v", h)
+			}
+			err = h.Check(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			act, err := ioutil.ReadAll(sr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(exp[i], act) {
+				t.Fatalf("shard mismatched, object size: %d, shard: %d", objSize, i)
+			}
+		}
+	}
+}
+
+func TestShardFile_Reconst(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := make([]byte, 5*d*blockSize+3)
+	fillRandom(obj)
+	files := makeShardFiles(t, s, obj)
+
+	src := make([]io.Reader, d+p)
+	for i := p; i < d+p; i++ {
+		sr, err := NewShardReader(bytes.NewReader(files[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src[i] = sr
+	}
+	dst := make([]io.Writer, d+p)
+	act := make([]*bytes.Buffer, p)
+	sws := make([]*ShardWriter, p)
+	for i := range act {
+		act[i] = new(bytes.Buffer)
+		sws[i], err = NewShardWriter(act[i], s.ShardHeader(i, int64(len(obj))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst[i] = sws[i]
+	}
+	err = s.Reconst(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, sw := range sws {
+		err = sw.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(files[i], act[i].Bytes()) {
+			t.Fatalf("shard file mismatched: %d", i)
+		}
+	}
+}
+
+func TestShardFile_Corrupted(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := make([]byte, 3*d*blockSize)
+	fillRandom(obj)
+	f := makeShardFiles(t, s, obj)[1]
+
+	read := func(f []byte) error {
+		sr, err := NewShardReader(bytes.NewReader(f))
+		if err != nil {
+			return err
+		}
+		_, err = ioutil.ReadAll(sr)
+		return err
+	}
+
+	b := append([]byte(nil), f...)
+	b[shardHeaderSize+blockSize+shardCRCSize+3] ^= 1 // Second block.
+	if err = read(b); !errors.Is(err, ErrShardChecksum) {
+		t.Fatalf("exp: %v, got: %v", ErrShardChecksum, err)
+	}
+
+	b = append([]byte(nil), f...)
+	b[6] ^= 1 // DataNum.
+	if err = read(b); !errors.Is(err, ErrShardChecksum) {
+		t.Fatalf("exp: %v, got: %v", ErrShardChecksum, err)
+	}
+
+	b = append([]byte(nil), f...)
+	b[0] = 'X'
+	if err = read(b); err != ErrNotShardFile {
+		t.Fatalf("exp: %v, got: %v", ErrNotShardFile, err)
+	}
+	if err = read(f[:10]); err != ErrNotShardFile {
+		t.Fatalf("exp: %v, got: %v", ErrNotShardFile, err)
+	}
+
+	h := s.ShardHeader(1, int64(len(obj)))
+	h.Polynomial = 0
+	b = append([]byte(nil), f...)
+	h.marshal(b)
+	if err = read(b); err != ErrIllegalPolynomial {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalPolynomial, err)
+	}
+
+	if err = read(f[:len(f)-blockSize-shardCRCSize]); err != io.ErrUnexpectedEOF {
+		t.Fatalf("exp: %v, got: %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestShardHeader_Check(t *testing.T) {
+	r, err := New(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.ShardHeader(5, 1000)
+	err = h.Check(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := New(3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Check(r2)
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+
+	r3, err := New(4, 2, WithPolynomial(0x11b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.Check(r3)
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+
+	// Different generator matrices of CustomMatrix.
+	gen := append([]byte(nil), r.GenMatrix...)
+	c1, err := NewWithMatrix(4, 2, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen[0] ^= 1
+	c2, err := NewWithMatrix(4, 2, gen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := NewStream(c1, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := s1.ShardHeader(5, 1000)
+	if err = ch.Check(c1); err != nil {
+		t.Fatal(err)
+	}
+	err = ch.Check(c2)
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+	ch2 := ch
+	ch2.MatrixChecksum = c2.MatrixChecksum()
+	err = ch.SameObject(&ch2)
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+
+	h.Index = 6
+	err = h.Check(r)
+	if err != ErrIllegalShardIndex {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalShardIndex, err)
+	}
+
+	h2 := s.ShardHeader(0, 1001)
+	err = h2.SameObject(&h)
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+}
+
+func TestShardWriter_Size(t *testing.T) {
+	r, err := New(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw, err := NewShardWriter(ioutil.Discard, s.ShardHeader(0, 100)) // Shard size: 25.
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sw.Write(make([]byte, 26))
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+	_, err = sw.Write(make([]byte, 24))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sw.Close()
+	if !errors.Is(err, ErrShardMismatch) {
+		t.Fatalf("exp: %v, got: %v", ErrShardMismatch, err)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+	"io"
+)
+
+var (
+	ErrShortData   = errors.New("not enough data")
+	ErrIllegalSize = errors.New("illegal object size")
+)
+
+// ShardSize returns the size of each vector when an object of objectSize bytes
+// is split into DataNum data vectors (see Split).
+func (r *RS) ShardSize(objectSize int) int {
+	return (objectSize + r.DataNum - 1) / r.DataNum
+}
+
+// Split splits data into DataNum data vectors and allocates ParityNum parity
+// vectors, all vectors are ShardSize(len(data)) bytes.
+// The tail of data vectors is zero padded, and the results are ready for Encode.
+//
+// All vectors share data's underlying array if cap(data) is at least
+// (DataNum+ParityNum)*ShardSize(len(data)), so bytes in data[len(data):cap(data)]
+// may be overwritten. Otherwise, data is copied into a new buffer,
+// and data's underlying array isn't modified.
+func (r *RS) Split(data []byte) (vects [][]byte, err error) {
+	if len(data) == 0 {
+		return nil, ErrShortData
+	}
+
+	d, p := r.DataNum, r.ParityNum
+	size := r.ShardSize(len(data))
+	dataSize, total := d*size, (d+p)*size
+
+	var buf []byte
+	if cap(data) >= total {
+		buf = data[:total]
+		// Padding.
+		pad := buf[len(data):dataSize]
+		for i := range pad {
+			pad[i] = 0
+		}
+	} else {
+		buf = make([]byte, total) // Zero padded.
+		copy(buf, data)
+	}
+
+	vects = make([][]byte, d+p)
+	for i := range vects {
+		vects[i] = buf[i*size : (i+1)*size : (i+1)*size]
+	}
+	return
+}
+
+// Join writes the first outSize bytes of data vectors into dst,
+// it's the reverse of Split, and padding is dropped.
+// vects must contain at least DataNum vectors, and all data vectors
+// must be available (see Reconst).
+func (r *RS) Join(dst io.Writer, vects [][]byte, outSize int) (err error) {
+	if outSize < 0 {
+		return ErrIllegalSize
+	}
+	if len(vects) < r.DataNum {
+		return ErrMismatchVects
+	}
+
+	size := 0
+	for _, v := range vects[:r.DataNum] {
+		size += len(v)
+	}
+	if size < outSize {
+		return ErrShortData
+	}
+
+	for _, v := range vects[:r.DataNum] {
+		if outSize == 0 {
+			break
+		}
+		if len(v) > outSize {
+			v = v[:outSize]
+		}
+		_, err = dst.Write(v)
+		if err != nil {
+			return
+		}
+		outSize -= len(v)
+	}
+	return
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRS_ShardSize(t *testing.T) {
+	r, err := New(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := [][2]int{{0, 0}, {1, 1}, {4, 1}, {5, 2}, {8, 2}, {9, 3}}
+	for _, c := range cases {
+		if got := r.ShardSize(c[0]); got != c[1] {
+			t.Fatalf("object size: %d, exp: %d, got: %d", c[0], c[1], got)
+		}
+	}
+}
+
+func TestRS_SplitJoin(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for objSize := 1; objSize <= testSize; objSize++ {
+		exp := make([]byte, objSize)
+		fillRandom(exp)
+
+		// Different capacities cover all buffer sharing branches.
+		shardSize := r.ShardSize(objSize)
+		for _, c := range []int{objSize, d * shardSize, (d + p) * shardSize} {
+			data := make([]byte, objSize, c)
+			copy(data, exp)
+			if c > objSize {
+				fillRandom(data[objSize:c]) // Dirty padding.
+			}
+
+			vects, err := r.Split(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vects) != d+p {
+				t.Fatal("vects number mismatched")
+			}
+			for i := range vects {
+				if len(vects[i]) != shardSize {
+					t.Fatalf("vect size mismatched, exp: %d, got: %d", shardSize, len(vects[i]))
+				}
+			}
+			for i := objSize; i < d*shardSize; i++ {
+				if vects[i/shardSize][i%shardSize] != 0 {
+					t.Fatal("padding isn't zero")
+				}
+			}
+
+			err = r.Encode(vects)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lost := rand.Intn(d)
+			for i := range vects[lost] {
+				vects[lost][i] = 0
+			}
+			err = r.Reconst(vects, nil, []int{lost})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := new(bytes.Buffer)
+			err = r.Join(buf, vects, objSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), exp) {
+				t.Fatalf("join mismatched, object size: %d", objSize)
+			}
+		}
+	}
+}
+
+func TestRS_SplitShare(t *testing.T) {
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objSize := d*testSize - 3 // With padding.
+	dataSize, total := d*testSize, (d+p)*testSize
+	for _, c := range []int{objSize, dataSize, total - 1, total, total + 1} {
+		data := make([]byte, objSize, c)
+		fillRandom(data[:c])
+		old := append([]byte(nil), data[:c]...)
+
+		vects, err := r.Split(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		shared := &vects[0][0] == &data[0]
+		if shared != (c >= total) {
+			t.Fatalf("cap: %d, shared: %t", c, shared)
+		}
+		if shared {
+			if &vects[d+p-1][testSize-1] != &data[:total][total-1] {
+				t.Fatalf("cap: %d, parity should share data", c)
+			}
+			continue
+		}
+		if !bytes.Equal(data[:c], old) {
+			t.Fatalf("cap: %d, data's underlying array is modified", c)
+		}
+	}
+}
+
+func TestRS_SplitJoinIllegal(t *testing.T) {
+	r, err := New(testDataNum, testParityNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Split(nil)
+	if err != ErrShortData {
+		t.Fatalf("exp: %v, got: %v", ErrShortData, err)
+	}
+
+	vects, err := r.Split(make([]byte, testDataNum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err = r.Join(buf, vects, testDataNum+1); err != ErrShortData {
+		t.Fatalf("exp: %v, got: %v", ErrShortData, err)
+	}
+	if err = r.Join(buf, vects, -1); err != ErrIllegalSize {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalSize, err)
+	}
+	if err = r.Join(buf, vects[:testDataNum-1], 1); err != ErrMismatchVects {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVects, err)
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream encodes/reconstructs data which is too big to be held in memory.
+// Data is processed block by block with RS, and buffers are allocated once
+// (about (DataNum+ParityNum) * blockSize bytes) and reused for all blocks.
+//
+// Stream layout:
+// Data is cut into blocks, and a full block has DataNum*blockSize bytes,
+// which are split into DataNum vectors (see RS.Split), each vector has
+// blockSize bytes. Vectors of each block are appended to their shards.
+// The last block may be short, its vectors have RS.ShardSize(n) bytes
+// (n is the size of the last block), and it's zero padded.
+//
+// A Stream is not safe for concurrent use.
+type Stream struct {
+	rs        *RS
+	blockSize int
+
+	buf   []byte   // Data vectors of a block, contiguous as the original data.
+	vects [][]byte // Vectors of the current block.
+	full  [][]byte // Vectors of a full block.
+}
+
+// NewStream creates a Stream with RS r, blockSize is the size of each vector
+// in a full block. Bigger blockSize costs more memory but has less overhead.
+func NewStream(r *RS, blockSize int) (s *Stream, err error) {
+	if blockSize <= 0 {
+		return nil, ErrIllegalSize
+	}
+
+	d, p := r.DataNum, r.ParityNum
+	s = &Stream{rs: r, blockSize: blockSize}
+	s.buf = make([]byte, (d+p)*blockSize)
+	s.full = make([][]byte, d+p)
+	for i := range s.full {
+		s.full[i] = s.buf[i*blockSize : (i+1)*blockSize : (i+1)*blockSize]
+	}
+	s.vects = make([][]byte, d+p)
+	return s, nil
+}
+
+// BlockSize returns the size of each vector in a full block.
+func (s *Stream) BlockSize() int {
+	return s.blockSize
+}
+
+// Encode reads all data from src, encodes it block by block,
+// and writes vectors of each block into dst (len(dst) must be DataNum+ParityNum).
+// dst[i] may be nil if shard i isn't needed.
+// It returns the number of bytes read from src, which is needed by decoding
+// for dropping padding.
+func (s *Stream) Encode(src io.Reader, dst []io.Writer) (size int64, err error) {
+	d, p := s.rs.DataNum, s.rs.ParityNum
+	if len(dst) != d+p {
+		return 0, ErrMismatchVects
+	}
+
+	dataBuf := s.buf[:d*s.blockSize]
+	for {
+		n, err := io.ReadFull(src, dataBuf)
+		if err == io.EOF {
+			return size, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return size, err
+		}
+		size += int64(n)
+
+		shardSize := s.blockSize
+		if n < len(dataBuf) { // Last block.
+			shardSize = s.rs.ShardSize(n)
+			pad := dataBuf[n : d*shardSize]
+			for i := range pad {
+				pad[i] = 0
+			}
+		}
+		for i := 0; i < d; i++ {
+			s.vects[i] = dataBuf[i*shardSize : (i+1)*shardSize]
+		}
+		for i := d; i < d+p; i++ {
+			s.vects[i] = s.full[i][:shardSize]
+		}
+
+		err = s.encodeBlock(dst)
+		if err != nil {
+			return size, err
+		}
+		if shardSize != s.blockSize {
+			return size, nil
+		}
+	}
+}
+
+// EncodeShards reads data vectors from DataNum readers, encodes them
+// block by block, and writes parity vectors into ParityNum writers.
+// Readers are read blockSize bytes at a time; if they have different lengths,
+// shorter ones are treated as zero padded.
+func (s *Stream) EncodeShards(data []io.Reader, parity []io.Writer) (err error) {
+	d, p := s.rs.DataNum, s.rs.ParityNum
+	if len(data) != d || len(parity) != p {
+		return ErrMismatchVects
+	}
+
+	dst := make([]io.Writer, d+p) // Data vectors are not written.
+	copy(dst[d:], parity)
+	done := make([]bool, d)
+	ns := make([]int, d)
+	for {
+		shardSize := 0
+		for i, r := range data {
+			ns[i] = 0
+			if done[i] {
+				continue
+			}
+			n, err := io.ReadFull(r, s.full[i])
+			if err != nil {
+				if err != io.EOF && err != io.ErrUnexpectedEOF {
+					return err
+				}
+				done[i] = true
+			}
+			ns[i] = n
+			if n > shardSize {
+				shardSize = n
+			}
+		}
+		if shardSize == 0 {
+			return nil
+		}
+
+		for i := range s.vects {
+			s.vects[i] = s.full[i][:shardSize]
+		}
+		for i, n := range ns {
+			pad := s.vects[i][n:]
+			for j := range pad {
+				pad[j] = 0
+			}
+		}
+
+		err = s.encodeBlock(dst)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// encodeBlock encodes s.vects and writes them into non-nil writers in dst.
+func (s *Stream) encodeBlock(dst []io.Writer) (err error) {
+	err = s.rs.Encode(s.vects)
+	if err != nil {
+		return
+	}
+	for i, w := range dst {
+		if w == nil {
+			continue
+		}
+		_, err = w.Write(s.vects[i])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+var ErrShortStream = errors.New("survived stream ended early")
+
+// Reconst reads survived shards from src, reconstructs lost shards block by
+// block, and writes them into dst. len(src) and len(dst) must be
+// DataNum+ParityNum. src[i] is nil if shard i is lost, and dst[i] is non-nil
+// if shard i needs reconstruction (dst takes precedence over src as in RS.Reconst).
+// Only DataNum survived shards are read.
+//
+// All survived shards must have the same length, otherwise it returns an error
+// wrapping ErrShortStream, which tells the index of the shard ended early.
+func (s *Stream) Reconst(src []io.Reader, dst []io.Writer) (err error) {
+	d, p := s.rs.DataNum, s.rs.ParityNum
+	if len(src) != d+p || len(dst) != d+p {
+		return ErrMismatchVects
+	}
+
+	var survived, needReconst []int
+	for i := range src {
+		if dst[i] != nil {
+			needReconst = append(needReconst, i)
+		} else if src[i] != nil && len(survived) < d {
+			survived = append(survived, i)
+		}
+	}
+	if len(needReconst) == 0 {
+		return nil
+	}
+	if len(survived) < d {
+		return ErrTooManyLost
+	}
+
+	for {
+		n, err := s.readBlock(src, survived)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		for i := range s.vects {
+			s.vects[i] = s.full[i][:n]
+		}
+		err = s.rs.Reconst(s.vects, survived, needReconst)
+		if err != nil {
+			return err
+		}
+		for _, i := range needReconst {
+			_, err = dst[i].Write(s.vects[i])
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Decode reads survived shards from src, and writes the original object
+// (size bytes, see Encode) into dst. len(src) must be DataNum+ParityNum,
+// and src[i] is nil if shard i is lost. Lost data vectors are reconstructed
+// block by block, and only DataNum survived shards are read.
+func (s *Stream) Decode(dst io.Writer, src []io.Reader, size int64) (err error) {
+	d, p := s.rs.DataNum, s.rs.ParityNum
+	if len(src) != d+p {
+		return ErrMismatchVects
+	}
+
+	var survived, needReconst []int
+	for i, r := range src {
+		if r != nil && len(survived) < d {
+			survived = append(survived, i)
+		} else if r == nil && i < d {
+			needReconst = append(needReconst, i)
+		}
+	}
+	if len(survived) < d {
+		return ErrTooManyLost
+	}
+
+	for size > 0 {
+		n, err := s.readBlock(src, survived)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("vect %d: %w", survived[0], ErrShortStream)
+		}
+
+		for i := range s.vects {
+			s.vects[i] = s.full[i][:n]
+		}
+		if len(needReconst) != 0 {
+			err = s.rs.Reconst(s.vects, survived, needReconst)
+			if err != nil {
+				return err
+			}
+		}
+		for _, v := range s.vects[:d] {
+			if int64(len(v)) > size {
+				v = v[:size]
+			}
+			_, err = dst.Write(v)
+			if err != nil {
+				return err
+			}
+			size -= int64(len(v))
+			if size == 0 {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// readBlock reads a block of survived shards from src,
+// and returns the size of vectors in this block (0 means all shards end).
+func (s *Stream) readBlock(src []io.Reader, survived []int) (size int, err error) {
+	for k, i := range survived {
+		n, err := io.ReadFull(src[i], s.full[i])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		if k == 0 {
+			size = n
+			continue
+		}
+		if n != size {
+			short := i
+			if n > size {
+				short = survived[0]
+			}
+			return 0, fmt.Errorf("vect %d: %w", short, ErrShortStream)
+		}
+	}
+	return
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// makeStreamShards encodes obj with Split & Encode block by block,
+// it's the reference of Stream layout.
+func makeStreamShards(t *testing.T, r *RS, obj []byte, blockSize int) [][]byte {
+	d, p := r.DataNum, r.ParityNum
+	shards := make([][]byte, d+p)
+	for off := 0; off < len(obj); off += d * blockSize {
+		end := off + d*blockSize
+		if end > len(obj) {
+			end = len(obj)
+		}
+		block := make([]byte, end-off)
+		copy(block, obj[off:end])
+		vects, err := r.Split(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.Encode(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range shards {
+			shards[i] = append(shards[i], vects[i]...)
+		}
+	}
+	return shards
+}
+
+func TestStream_Encode(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objSize := range []int{0, 1, 63, d * blockSize, d*blockSize + 1, 5*d*blockSize - 7, 5 * d * blockSize} {
+		obj := make([]byte, objSize)
+		fillRandom(obj)
+		exp := makeStreamShards(t, r, obj, blockSize)
+
+		bufs := make([]*bytes.Buffer, d+p)
+		dst := make([]io.Writer, d+p)
+		for i := range dst {
+			bufs[i] = new(bytes.Buffer)
+			dst[i] = bufs[i]
+		}
+		dst[1] = nil // Skip a shard.
+
+		size, err := s.Encode(iotest.HalfReader(bytes.NewReader(obj)), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size != int64(objSize) {
+			t.Fatalf("size mismatched, exp: %d, got: %d", objSize, size)
+		}
+		for i := range bufs {
+			if i == 1 {
+				if bufs[i].Len() != 0 {
+					t.Fatal("nil writer should be skipped")
+				}
+				continue
+			}
+			if !bytes.Equal(exp[i], bufs[i].Bytes()) {
+				t.Fatalf("shard mismatched, object size: %d, shard: %d", objSize, i)
+			}
+		}
+	}
+}
+
+func TestStream_EncodeShards(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objSize := range []int{1, d*blockSize + 1, 5 * d * blockSize} {
+		obj := make([]byte, objSize)
+		fillRandom(obj)
+		exp := makeStreamShards(t, r, obj, blockSize)
+
+		data := make([]io.Reader, d)
+		for i := range data {
+			data[i] = iotest.HalfReader(bytes.NewReader(exp[i]))
+		}
+		bufs := make([]*bytes.Buffer, p)
+		parity := make([]io.Writer, p)
+		for i := range parity {
+			bufs[i] = new(bytes.Buffer)
+			parity[i] = bufs[i]
+		}
+		err = s.EncodeShards(data, parity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range bufs {
+			if !bytes.Equal(exp[d+i], bufs[i].Bytes()) {
+				t.Fatalf("parity mismatched, object size: %d, parity: %d", objSize, i)
+			}
+		}
+	}
+}
+
+func TestStream_EncodeReadError(t *testing.T) {
+	r, err := New(4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := make([]io.Writer, 6)
+	_, err = s.Encode(iotest.TimeoutReader(bytes.NewReader(make([]byte, 1024))), dst)
+	if err != iotest.ErrTimeout {
+		t.Fatalf("exp: %v, got: %v", iotest.ErrTimeout, err)
+	}
+	_, err = s.Encode(bytes.NewReader(nil), dst[:5])
+	if err != ErrMismatchVects {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVects, err)
+	}
+}
+
+func TestStream_Reconst(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objSize := range []int{1, d*blockSize + 1, 5 * d * blockSize} {
+		obj := make([]byte, objSize)
+		fillRandom(obj)
+		exp := makeStreamShards(t, r, obj, blockSize)
+
+		survived, needReconst := genIdxForTest(d, p, d, p)
+		src := make([]io.Reader, d+p)
+		for _, i := range survived {
+			src[i] = iotest.HalfReader(bytes.NewReader(exp[i]))
+		}
+		bufs := make(map[int]*bytes.Buffer)
+		dst := make([]io.Writer, d+p)
+		for _, i := range needReconst {
+			bufs[i] = new(bytes.Buffer)
+			dst[i] = bufs[i]
+		}
+
+		err = s.Reconst(src, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, buf := range bufs {
+			if !bytes.Equal(exp[i], buf.Bytes()) {
+				t.Fatalf("shard mismatched, object size: %d, shard: %d", objSize, i)
+			}
+		}
+	}
+}
+
+func TestStream_ReconstShort(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := make([]byte, 3*d*blockSize)
+	fillRandom(obj)
+	shards := makeStreamShards(t, r, obj, blockSize)
+
+	src := make([]io.Reader, d+p)
+	for i := 1; i < d+p; i++ {
+		src[i] = bytes.NewReader(shards[i])
+	}
+	src[3] = bytes.NewReader(shards[3][:2*blockSize+5]) // Ends early.
+	dst := make([]io.Writer, d+p)
+	dst[0] = new(bytes.Buffer)
+
+	err = s.Reconst(src, dst)
+	if !errors.Is(err, ErrShortStream) {
+		t.Fatalf("exp: %v, got: %v", ErrShortStream, err)
+	}
+	if !strings.Contains(err.Error(), "vect 3") {
+		t.Fatalf("error should tell the short vect: %v", err)
+	}
+
+	src[3], src[4], src[5] = nil, nil, nil
+	err = s.Reconst(src, dst)
+	if err != ErrTooManyLost {
+		t.Fatalf("exp: %v, got: %v", ErrTooManyLost, err)
+	}
+}
+
+func TestStream_Decode(t *testing.T) {
+	d, p, blockSize := 4, 2, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStream(r, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objSize := range []int{1, d*blockSize - 1, d * blockSize, 5*d*blockSize + 3} {
+		obj := make([]byte, objSize)
+		fillRandom(obj)
+		shards := makeStreamShards(t, r, obj, blockSize)
+
+		for _, lost := range [][]int{nil, {1}, {0, 3}, {4, 5}, {2, 5}} {
+			src := make([]io.Reader, d+p)
+			for i := range src {
+				src[i] = iotest.OneByteReader(bytes.NewReader(shards[i]))
+			}
+			for _, i := range lost {
+				src[i] = nil
+			}
+			act := new(bytes.Buffer)
+			err = s.Decode(act, src, int64(objSize))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(obj, act.Bytes()) {
+				t.Fatalf("decode mismatched, object size: %d, lost: %v", objSize, lost)
+			}
+		}
+
+		src := make([]io.Reader, d+p)
+		for i := range src {
+			src[i] = bytes.NewReader(shards[i])
+		}
+		err = s.Decode(ioutil.Discard, src, int64(objSize)+int64(d*blockSize))
+		if !errors.Is(err, ErrShortStream) {
+			t.Fatalf("exp: %v, got: %v", ErrShortStream, err)
+		}
+	}
+}
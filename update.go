@@ -0,0 +1,189 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"errors"
+
+	xor "github.com/templexxx/xorsimd"
+)
+
+// UpdateRange is like Update, but only a part of data vector row changes:
+// oldData and newData are the old/new content of bytes in [off, off+len(newData))
+// of the data vector, and only the same range of parity vectors is updated.
+// parity contains full parity vectors.
+//
+// It's useful for small writes inside big vectors, which don't need to read
+// or rewrite entire parity vectors.
+func (r *RS) UpdateRange(oldData, newData []byte, row, off int, parity [][]byte) (err error) {
+
+	err = r.checkUpdateRange(oldData, newData, row, off, parity)
+	if err != nil {
+		return
+	}
+
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	n := len(newData)
+	pv := ws.vs[1 : 1+r.ParityNum] // ws.vs[0] is used by update.
+	for i := range pv {
+		pv[i] = parity[i][off : off+n]
+	}
+	r.update(ws, oldData, newData, row, pv)
+	return nil
+}
+
+func (r *RS) checkUpdateRange(oldData, newData []byte, row, off int, parity [][]byte) (err error) {
+	if len(parity) != r.ParityNum {
+		return ErrMismatchParityNum
+	}
+	n := len(newData)
+	if n == 0 {
+		return ErrZeroVectSize
+	}
+	if n != len(oldData) {
+		return ErrMismatchVectSize
+	}
+	size := len(parity[0])
+	for i := range parity {
+		if len(parity[i]) != size {
+			return ErrMismatchVectSize
+		}
+	}
+	if row >= r.DataNum || row < 0 {
+		return ErrIllegalVectIndex
+	}
+	return checkRange(size, off, n)
+}
+
+// UpdateRows updates parity vectors when several data vectors change.
+// rows are indexes of the changed data vectors, and oldData[i]/newData[i]
+// are the old/new content of data vector rows[i].
+//
+// It's like calling Update for each row, but parity vectors are read and
+// written only once: deltas (old XOR new) of all rows are computed chunk by
+// chunk, then multiplied by the generator sub-matrix of rows (like Replace).
+func (r *RS) UpdateRows(rows []int, oldData, newData [][]byte, parity [][]byte) (err error) {
+
+	err = r.checkUpdateRows(rows, oldData, newData, parity)
+	if err != nil {
+		return
+	}
+
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	rn, p := len(rows), r.ParityNum
+	gm := r.makeUpdateMatrix(ws.gm, rows)
+
+	size := len(oldData[0])
+	splitSize := getSplitSize(size)
+	buf := ws.getBuf(rn * splitSize)
+	dc, pc := ws.vs[:rn], ws.vs[rn:rn+p]
+
+	for start := 0; start < size; start += splitSize {
+		end := start + splitSize
+		if end > size {
+			end = size
+		}
+		n := end - start
+		for i := range dc {
+			dc[i] = buf[i*splitSize : i*splitSize+n]
+			ws.src2[0], ws.src2[1] = oldData[i][start:end], newData[i][start:end]
+			xor.Encode(dc[i], ws.src2)
+		}
+		for j := range pc {
+			pc[j] = parity[j][start:end]
+		}
+		r.encodePart(gm, 0, n, dc, pc, true)
+	}
+	return nil
+}
+
+var ErrDuplicateRows = errors.New("duplicate rows")
+
+func (r *RS) checkUpdateRows(rows []int, oldData, newData [][]byte, parity [][]byte) (err error) {
+	if len(rows) == 0 || len(rows) > r.DataNum {
+		return ErrTooManyReplace
+	}
+	if len(oldData) != len(rows) || len(newData) != len(rows) {
+		return ErrMismatchReplace
+	}
+	if len(parity) != r.ParityNum {
+		return ErrMismatchParityNum
+	}
+
+	size := len(oldData[0])
+	if size == 0 {
+		return ErrZeroVectSize
+	}
+	for i := range rows {
+		if len(oldData[i]) != size || len(newData[i]) != size {
+			return ErrMismatchVectSize
+		}
+	}
+	for i := range parity {
+		if len(parity[i]) != size {
+			return ErrMismatchVectSize
+		}
+	}
+
+	for i, row := range rows {
+		if row < 0 || row >= r.DataNum {
+			return ErrIllegalVectIndex
+		}
+		for _, row2 := range rows[:i] {
+			if row == row2 {
+				return ErrDuplicateRows
+			}
+		}
+	}
+	return
+}
+
+// ComputeDelta returns oldData XOR newData, which is the parity delta of
+// a changed data vector before being multiplied by coefficients.
+//
+// ComputeDelta and ApplyDelta split Update for parity vectors which are
+// not stored with data vectors: the data node computes the delta once and
+// sends it to every parity node, then each parity node applies it independently.
+func (r *RS) ComputeDelta(oldData, newData []byte) (delta []byte, err error) {
+	if len(newData) == 0 {
+		return nil, ErrZeroVectSize
+	}
+	if len(oldData) != len(newData) {
+		return nil, ErrMismatchVectSize
+	}
+	delta = make([]byte, len(newData))
+	xor.Encode(delta, [][]byte{oldData, newData})
+	return
+}
+
+// ApplyDelta folds delta (see ComputeDelta) of data vector dataRow into parity,
+// which is the parity vector with index parityIdx in [0, ParityNum).
+// len(delta) must equal len(parity).
+func (r *RS) ApplyDelta(delta []byte, dataRow, parityIdx int, parity []byte) (err error) {
+	if len(delta) == 0 {
+		return ErrZeroVectSize
+	}
+	if len(delta) != len(parity) {
+		return ErrMismatchVectSize
+	}
+	if dataRow < 0 || dataRow >= r.DataNum || parityIdx < 0 || parityIdx >= r.ParityNum {
+		return ErrIllegalVectIndex
+	}
+
+	ws := r.getWorkspace()
+	defer r.putWorkspace(ws)
+
+	gm := ws.gm[:1]
+	gm[0] = r.GenMatrix[parityIdx*r.DataNum+dataRow]
+	dv, pv := ws.vs[:1], ws.vs[1:2]
+	dv[0], pv[0] = delta, parity
+	r.encodeRange(gm, dv, pv, 0, len(delta), true)
+	return nil
+}
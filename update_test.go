@@ -0,0 +1,282 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRS_UpdateRange(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 17, testSize, 64*kib + 20} {
+		for i := 0; i < 32; i++ {
+			act := make([][]byte, d+p)
+			exp := make([][]byte, d+p)
+			for j := range act {
+				act[j], exp[j] = make([]byte, size), make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(exp[j])
+				copy(act[j], exp[j])
+			}
+			err = r.Encode(act)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			row := rand.Intn(d)
+			off, n := randRange(size)
+			newData := make([]byte, n)
+			fillRandom(newData)
+			err = r.UpdateRange(act[row][off:off+n], newData, row, off, act[d:])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			copy(exp[row][off:], newData)
+			err = r.Encode(exp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := d; j < d+p; j++ {
+				if !bytes.Equal(act[j], exp[j]) {
+					t.Fatalf("update range failed: vect: %d, size: %d, off: %d, n: %d", j, size, off, n)
+				}
+			}
+		}
+	}
+}
+
+func TestRS_UpdateRangeIllegal(t *testing.T) {
+	d, p, size := testDataNum, testParityNum, 64
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parity := make([][]byte, p)
+	for j := range parity {
+		parity[j] = make([]byte, size)
+	}
+	buf := make([]byte, 16)
+	if err = r.UpdateRange(buf, buf, 0, size-8, parity); err != ErrIllegalRange {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalRange, err)
+	}
+	if err = r.UpdateRange(buf, buf, d, 0, parity); err != ErrIllegalVectIndex {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVectIndex, err)
+	}
+	if err = r.UpdateRange(buf, buf[:8], 0, 0, parity); err != ErrMismatchVectSize {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVectSize, err)
+	}
+}
+
+func TestRS_UpdateRows(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	testUpdateRows(t, testDataNum, testParityNum, testSize, 128)
+	testUpdateRows(t, testDataNum, testParityNum, 64*kib+20, 8) // Multi-chunk.
+}
+
+func testUpdateRows(t *testing.T, d, p, size, loop int) {
+
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < loop; i++ {
+		act := make([][]byte, d+p)
+		exp := make([][]byte, d+p)
+		for j := range act {
+			act[j], exp[j] = make([]byte, size), make([]byte, size)
+		}
+		for j := 0; j < d; j++ {
+			fillRandom(exp[j])
+			copy(act[j], exp[j])
+		}
+		err = r.Encode(act)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rows := makeReplaceRowRandom(d)
+		oldData := make([][]byte, len(rows))
+		newData := make([][]byte, len(rows))
+		for j, row := range rows {
+			oldData[j] = act[row]
+			newData[j] = make([]byte, size)
+			fillRandom(newData[j])
+		}
+		err = r.UpdateRows(rows, oldData, newData, act[d:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j, row := range rows {
+			copy(exp[row], newData[j])
+		}
+		err = r.Encode(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := d; j < d+p; j++ {
+			if !bytes.Equal(act[j], exp[j]) {
+				t.Fatalf("update rows failed: vect: %d, size: %d, rows: %v", j, size, rows)
+			}
+		}
+	}
+}
+
+func TestRS_UpdateRowsIllegal(t *testing.T) {
+	d, p, size := testDataNum, testParityNum, 16
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vects := make([][]byte, d+p)
+	for j := range vects {
+		vects[j] = make([]byte, size)
+	}
+
+	err = r.UpdateRows([]int{1, 1}, vects[:2], vects[2:4], vects[d:])
+	if err != ErrDuplicateRows {
+		t.Fatalf("exp: %v, got: %v", ErrDuplicateRows, err)
+	}
+	err = r.UpdateRows([]int{d}, vects[:1], vects[1:2], vects[d:])
+	if err != ErrIllegalVectIndex {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVectIndex, err)
+	}
+	err = r.UpdateRows([]int{0}, vects[:1], vects[1:3], vects[d:])
+	if err != ErrMismatchReplace {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchReplace, err)
+	}
+}
+
+func BenchmarkRS_UpdateRows(b *testing.B) {
+	d, p := 10, 4
+	size := 8 * kib
+
+	for n := 1; n <= p; n++ {
+		b.Run(fmt.Sprintf("(%d+%d)-%s-update_%d_data_vects-%s",
+			d, p, byteToStr(size), n, featToStr(getCPUFeature())),
+			func(b *testing.B) {
+				vects := make([][]byte, d+p)
+				for j := range vects {
+					vects[j] = make([]byte, size)
+				}
+				for j := 0; j < d; j++ {
+					fillRandom(vects[j])
+				}
+				r, err := New(d, p)
+				if err != nil {
+					b.Fatal(err)
+				}
+				err = r.Encode(vects)
+				if err != nil {
+					b.Fatal(err)
+				}
+				rows := make([]int, n)
+				newData := make([][]byte, n)
+				for j := range rows {
+					rows[j] = j
+					newData[j] = make([]byte, size)
+					fillRandom(newData[j])
+				}
+
+				b.SetBytes(int64((2*n + p + p) * size))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					err = r.UpdateRows(rows, vects[:n], newData, vects[d:])
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+	}
+}
+
+func TestRS_ComputeApplyDelta(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{1, 17, testSize} {
+		for row := 0; row < d; row++ {
+			act := make([][]byte, d+p)
+			exp := make([][]byte, d+p)
+			for j := range act {
+				act[j], exp[j] = make([]byte, size), make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(exp[j])
+				copy(act[j], exp[j])
+			}
+			err = r.Encode(act)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			newData := make([]byte, size)
+			fillRandom(newData)
+			delta, err := r.ComputeDelta(act[row], newData)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := 0; j < p; j++ { // Parity nodes apply delta independently.
+				err = r.ApplyDelta(delta, row, j, act[d+j])
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			copy(exp[row], newData)
+			err = r.Encode(exp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := d; j < d+p; j++ {
+				if !bytes.Equal(act[j], exp[j]) {
+					t.Fatalf("apply delta failed: vect: %d, size: %d", j, size)
+				}
+			}
+		}
+	}
+}
+
+func TestRS_ApplyDeltaIllegal(t *testing.T) {
+	r, err := New(testDataNum, testParityNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	if err = r.ApplyDelta(buf, 0, testParityNum, buf); err != ErrIllegalVectIndex {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVectIndex, err)
+	}
+	if err = r.ApplyDelta(buf, testDataNum, 0, buf); err != ErrIllegalVectIndex {
+		t.Fatalf("exp: %v, got: %v", ErrIllegalVectIndex, err)
+	}
+	if err = r.ApplyDelta(buf, 0, 0, buf[:8]); err != ErrMismatchVectSize {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVectSize, err)
+	}
+	if _, err = r.ComputeDelta(buf, buf[:8]); err != ErrMismatchVectSize {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVectSize, err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ParityMismatch describes a parity vector which disagrees with data vectors.
+type ParityMismatch struct {
+	Row    int // Row is the index of the parity vector in vects.
+	Offset int // Offset is the first byte offset where the parity vector differs.
+}
+
+// Verify checks whether parity vectors match data vectors.
+// vects has the same layout as in Encode, and it is read-only here.
+//
+// Parity is recomputed chunk by chunk (see getSplitSize), so only
+// a chunk-sized scratch buffer is allocated for each parity vector.
+func (r *RS) Verify(vects [][]byte) (ok bool, err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	ms := r.verify(vects, 0, len(vects[0]), true)
+	return len(ms) == 0, nil
+}
+
+// VerifyMismatch is like Verify, but returns all parity vectors which
+// disagree with data vectors and the first differing byte offset of each.
+// Results are sorted by Row. An empty result means the stripe is consistent.
+func (r *RS) VerifyMismatch(vects [][]byte) (ms []ParityMismatch, err error) {
+	err = r.checkEncode(vects)
+	if err != nil {
+		return
+	}
+	return r.verify(vects, 0, len(vects[0]), false), nil
+}
+
+// verify recomputes parity of bytes in [start, end) into chunk-sized
+// scratch buffers and compares the results with the given parity vectors.
+// If stopFirst is true, it returns as soon as any mismatch is found.
+func (r *RS) verify(vects [][]byte, start, end int, stopFirst bool) (ms []ParityMismatch) {
+	d, p := r.DataNum, r.ParityNum
+	dv, pv := vects[:d], vects[d:]
+	splitSize := getSplitSize(end - start)
+
+	buf := make([]byte, p*splitSize)
+	dc := make([][]byte, d)
+	pc := make([][]byte, p)
+	found := make([]bool, p)
+
+	for start < end {
+		next := start + splitSize
+		if next > end {
+			next = end
+		}
+		n := next - start
+		for i := range dc {
+			dc[i] = dv[i][start:next]
+		}
+		for j := range pc {
+			pc[j] = buf[j*splitSize : j*splitSize+n]
+		}
+		r.encodePart(r.GenMatrix, 0, n, dc, pc, false)
+
+		for j := range pc {
+			if found[j] {
+				continue
+			}
+			if !bytes.Equal(pc[j], pv[j][start:next]) {
+				found[j] = true
+				ms = append(ms, ParityMismatch{Row: d + j, Offset: start + firstDiff(pc[j], pv[j][start:next])})
+				if stopFirst || len(ms) == p {
+					sortParityMismatch(ms)
+					return
+				}
+			}
+		}
+		start = next
+	}
+	sortParityMismatch(ms)
+	return
+}
+
+// firstDiff returns the index of the first differing byte of a and b,
+// or -1 if they are equal. len(a) must equal len(b).
+func firstDiff(a, b []byte) int {
+	for i := range a {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortParityMismatch(ms []ParityMismatch) {
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Row < ms[j].Row
+	})
+}
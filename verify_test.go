@@ -0,0 +1,177 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRS_Verify(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for size := 1; size <= testSize; size++ {
+		vects := make([][]byte, d+p)
+		for j := range vects {
+			vects[j] = make([]byte, size)
+		}
+		for j := 0; j < d; j++ {
+			fillRandom(vects[j])
+		}
+		err = r.Encode(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ok, err := r.Verify(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatalf("verify failed on consistent stripe, size: %d", size)
+		}
+
+		// Corrupt one data byte; every parity vector depends on it.
+		row, off := rand.Intn(d), rand.Intn(size)
+		vects[row][off] ^= 1
+		ok, err = r.Verify(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Fatalf("verify passed on corrupted stripe, size: %d", size)
+		}
+		ms, err := r.VerifyMismatch(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != p {
+			t.Fatalf("mismatch number wrong, exp: %d, got: %d", p, len(ms))
+		}
+		for j, m := range ms {
+			if m.Row != d+j || m.Offset != off {
+				t.Fatalf("mismatch wrong, exp: {%d %d}, got: %v", d+j, off, m)
+			}
+		}
+	}
+}
+
+func TestRS_VerifyMismatch(t *testing.T) {
+	rand.Seed(time.Now().UnixNano())
+
+	d, p := testDataNum, testParityNum
+	size := 64 * kib // Bigger than split size for covering multi-chunk.
+	r, err := New(d, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vects := make([][]byte, d+p)
+	for j := range vects {
+		vects[j] = make([]byte, size)
+	}
+	for j := 0; j < d; j++ {
+		fillRandom(vects[j])
+	}
+	err = r.Encode(vects)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 128; i++ {
+		exp := make(map[int]int)
+		for j := 0; j < p; j++ {
+			if rand.Intn(2) == 0 {
+				continue
+			}
+			first := size
+			for k := 0; k < 3; k++ {
+				off := rand.Intn(size)
+				vects[d+j][off] ^= byte(rand.Intn(255) + 1)
+				if off < first {
+					first = off
+				}
+			}
+			exp[d+j] = first
+		}
+
+		ms, err := r.VerifyMismatch(vects)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ms) != len(exp) {
+			t.Fatalf("mismatch number wrong, exp: %d, got: %d", len(exp), len(ms))
+		}
+		for j, m := range ms {
+			if j > 0 && ms[j-1].Row >= m.Row {
+				t.Fatal("mismatches unsorted")
+			}
+			if off, ok := exp[m.Row]; !ok || off != m.Offset {
+				t.Fatalf("mismatch wrong, row: %d, exp offset: %d, got: %d", m.Row, off, m.Offset)
+			}
+		}
+
+		err = r.Encode(vects) // Restore parity.
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRS_VerifyIllegal(t *testing.T) {
+	r, err := New(testDataNum, testParityNum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Verify(make([][]byte, testDataNum))
+	if err != ErrMismatchVects {
+		t.Fatalf("exp: %v, got: %v", ErrMismatchVects, err)
+	}
+}
+
+func BenchmarkRS_Verify(b *testing.B) {
+	d, p := 10, 4
+	size := 8 * kib
+
+	b.Run(fmt.Sprintf("(%d+%d)-%s-%s", d, p, byteToStr(size), featToStr(getCPUFeature())),
+		func(b *testing.B) {
+			vects := make([][]byte, d+p)
+			for j := range vects {
+				vects[j] = make([]byte, size)
+			}
+			for j := 0; j < d; j++ {
+				fillRandom(vects[j])
+			}
+			r, err := New(d, p)
+			if err != nil {
+				b.Fatal(err)
+			}
+			err = r.Encode(vects)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.SetBytes(int64((d + p) * size))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ok, err := r.Verify(vects)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if !ok {
+					b.Fatal("verify failed")
+				}
+			}
+		})
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Temple3x (temple3x@gmail.com)
+//
+// Use of this source code is governed by the MIT License
+// that can be found in the LICENSE file.
+
+package reedsolomon
+
+// workspace holds scratch buffers for one Reconst/Update/Replace call.
+// Workspaces are reused through RS.wsPool, so that these methods don't
+// allocate in steady state.
+type workspace struct {
+	status []uint8  // Vector status, see checkReconst.
+	ints   []int    // Survived & needReconst indexes, see checkReconst.
+	vs     [][]byte // Vectors passed to encodeRange.
+	src2   [][]byte // Source of xor.Encode: [old, new].
+	gm     []byte   // Generator matrix passed to encodeRange.
+	buf    []byte   // Grows on demand, see Update.
+}
+
+func (r *RS) getWorkspace() *workspace {
+	if ws := r.wsPool.Get(); ws != nil {
+		return ws.(*workspace)
+	}
+	d, p := r.DataNum, r.ParityNum
+	return &workspace{
+		status: make([]uint8, d+p),
+		ints:   make([]int, d+2*p),
+		vs:     make([][]byte, d+p),
+		src2:   make([][]byte, 2),
+		gm:     make([]byte, p*d),
+	}
+}
+
+func (r *RS) putWorkspace(ws *workspace) {
+	for i := range ws.vs {
+		ws.vs[i] = nil // Don't keep vectors alive.
+	}
+	ws.src2[0], ws.src2[1] = nil, nil
+	r.wsPool.Put(ws)
+}
+
+// getBuf returns a buffer with n bytes, its content is undefined.
+func (ws *workspace) getBuf(n int) []byte {
+	if cap(ws.buf) < n {
+		ws.buf = make([]byte, n)
+	}
+	return ws.buf[:n]
+}